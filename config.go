@@ -2,16 +2,51 @@ package config
 
 import (
 	"io/ioutil"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
 // Config struct to hold configuration values
 type Config struct {
-	RestAPIServerAddress string `yaml:"rest_api_server_address"`
-	GRPCNetwork          string `yaml:"grpc_network"`
-	GRPCServerAddress    string `yaml:"grpc_server_address"`
-	MongoURI             string `yaml:"mongo_uri"`
+	RestAPIServerAddress string        `yaml:"rest_api_server_address"`
+	GRPCNetwork          string        `yaml:"grpc_network"`
+	GRPCServerAddress    string        `yaml:"grpc_server_address"`
+	MongoURI             string        `yaml:"mongo_uri"`
+	UseInMemoryDB        bool          `yaml:"use_in_memory_db"` // run against the in-memory DAO instead of MongoDB
+	SessionTTL           time.Duration `yaml:"session_ttl"`      // how long a session token issued on login stays valid
+	// users who have not sent a heartbeat for heartbeat_timeout are marked offline.
+	// the check runs every heartbeat_sweep_interval
+	HeartbeatTimeout       time.Duration `yaml:"heartbeat_timeout"`
+	HeartbeatSweepInterval time.Duration `yaml:"heartbeat_sweep_interval"`
+	// time given to in-flight requests and open streams to finish on SIGINT/SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// game parties last default_party_duration unless the leader asks for a duration
+	// between min_party_duration and max_party_duration, extensions included
+	DefaultPartyDuration time.Duration `yaml:"default_party_duration"`
+	MinPartyDuration     time.Duration `yaml:"min_party_duration"`
+	MaxPartyDuration     time.Duration `yaml:"max_party_duration"`
+	// most players a game party can have, leader included. The leader can ask for a smaller capacity
+	MaxPartySize int `yaml:"max_party_size"`
+	// when the leader leaves, promote the player who joined first instead of ending the party
+	AutoPromoteLeader bool `yaml:"auto_promote_leader"`
+	// party invitations not answered within invite_ttl expire. Checked every invite_sweep_interval
+	InviteTTL           time.Duration `yaml:"invite_ttl"`
+	InviteSweepInterval time.Duration `yaml:"invite_sweep_interval"`
+	// a user is in one party at a time. Creating or joining another party leaves the current one instead of being rejected
+	AutoLeaveParty bool `yaml:"auto_leave_party"`
+	// the match starts ready_check_timeout after the leader's ready-check, even if not every player is ready.
+	// Checked every ready_check_sweep_interval
+	ReadyCheckTimeout       time.Duration `yaml:"ready_check_timeout"`
+	ReadyCheckSweepInterval time.Duration `yaml:"ready_check_sweep_interval"`
+	// latest chat messages of a party kept in memory and replayed to players opening the party chat
+	ChatHistorySize int `yaml:"chat_history_size"`
+	// longest party chat message accepted, in bytes
+	ChatMaxMessageSize int `yaml:"chat_max_message_size"`
+	// longest direct message accepted, in bytes
+	DirectMessageMaxSize int `yaml:"direct_message_max_size"`
+	// also store the party chat messages in the partychat collection. History survives restarts
+	PersistPartyChat bool `yaml:"persist_party_chat"`
 }
 
 // LoadConfig function to read from the YAML file
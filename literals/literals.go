@@ -13,25 +13,39 @@ const (
 
 	EmptyString = ""
 
+	// authentication
+	AuthorizationHeader      = "Authorization"
+	AuthorizationMetadataKey = "authorization" // gRPC metadata keys are lowercase
+	BearerPrefix             = "Bearer "
+
 	// MongoDB
-	Database            = "social-presence-system"
-	UsersCollection     = "users"
-	FriendsCollection   = "friends"
-	GamePartyCollection = "gameparty"
-	UserCredsCollection = "usercreds"
+	Database                 = "social-presence-system"
+	UsersCollection          = "users"
+	FriendsCollection        = "friends"
+	GamePartyCollection      = "gameparty"
+	UserCredsCollection      = "usercreds"
+	PartyChatCollection      = "partychat"
+	DirectMessagesCollection = "directmessages"
 
 	// MongoDB operators
-	MongoOr       = "$or"
-	MongoIn       = "$in"
-	MongoAnd      = "$and"
-	MongoSet      = "$set"
-	MongoExpr     = "$expr"
-	MongoLessThan = "$lt"
-	MongoAdd      = "$add"
-	MongoPush     = "$push"
-	MongoPull     = "$pull"
-	MongoEach     = "$each"
-	MongoExists   = "$exists"
+	MongoOr            = "$or"
+	MongoIn            = "$in"
+	MongoAnd           = "$and"
+	MongoSet           = "$set"
+	MongoExpr          = "$expr"
+	MongoLessThan      = "$lt"
+	MongoAdd           = "$add"
+	MongoDivide        = "$divide"
+	MongoPush          = "$push"
+	MongoPull          = "$pull"
+	MongoEach          = "$each"
+	MongoExists        = "$exists"
+	MongoUnset         = "$unset"
+	MongoNotEqual      = "$ne"
+	MongoMatch         = "$match"
+	MongoGroup         = "$group"
+	MongoSum           = "$sum"
+	MongoLessThanEqual = "$lte"
 
 	// MongoDB fields
 	MongoID          = "_id"
@@ -44,6 +58,19 @@ const (
 	MongoCreatedBy   = "createdBy"
 	MongoStartTime   = "startTime"
 	MongoDuration    = "duration"
+	MongoCapacity    = "capacity"
+	MongoLeader      = "leader"
+	MongoVisibility  = "visibility"
+	MongoJoinCode    = "joinCode"
+	MongoReadyCheck  = "readyCheck"
+	MongoPartyId     = "partyId"
+	MongoSentAt      = "sentAt"
+	MongoSenderId    = "senderId"
+	MongoRecipientId = "recipientId"
+	MongoDelivered   = "delivered"
+	MongoRead        = "read"
+	MongoCount       = "count"
+	MongoLastSeen    = "lastSeen"
 
 	MongoGamePartyInvitees = "invitees"
 	MongoGamePartyAccepted = "accepted"
@@ -52,5 +79,11 @@ const (
 	MongoGamePartyExited   = "exited"
 	MongoGamePartyRemoved  = "removed"
 
-	MongoPlayersDotAccess = "players."
+	MongoPlayersDotAccess   = "players."
+	MongoJoinedAtDotAccess  = "joinedAt."
+	MongoInvitedByDotAccess = "invitedBy."
+	MongoInvitedAtDotAccess = "invitedAt."
+
+	MongoJoinRequestsDotAccess        = "joinRequests."
+	MongoReadyCheckResponsesDotAccess = "readyCheck.responses."
 )
@@ -10,5 +10,14 @@ const (
 	LLFriendsFound            = "FriendsFound"
 	LLRequestedUserIds        = "RequestedUserIds"
 	LLUsersFound              = "UsersFound"
+	LLStaleUserIds            = "staleUserIds"
+	LLHeartbeatTimeout        = "heartbeatTimeout"
 	LLInternalError           = "internalError"
+	LLTopic                   = "topic"
+	LLEventType               = "eventType"
+	LLEndedPartyIds           = "endedPartyIds"
+	LLResetUserIds            = "resetUserIds"
+	LLSkippedUserIds          = "skippedUserIds"
+	LLExpiredInviteeIds       = "expiredInviteeIds"
+	LLInviteTTL               = "inviteTTL"
 )
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// message sent on the chat of a game party
+type ChatMessage struct {
+	MessageId string    `bson:"_id" json:"messageId"`
+	PartyId   string    `bson:"partyId" json:"partyId"`
+	UserId    string    `bson:"userId" json:"userId"` // leader or joined player who sent the message
+	Text      string    `bson:"text" json:"text"`
+	SentAt    time.Time `bson:"sentAt" json:"sentAt"`
+}
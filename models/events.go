@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+type PresenceEventType string
+
+const (
+	PresenceEventUndefined            PresenceEventType = "undefined"
+	PresenceEventUserOnline           PresenceEventType = "user-online"            // user logged in or came back after missing heartbeats
+	PresenceEventUserOffline          PresenceEventType = "user-offline"           // user logged out or stopped sending heartbeats
+	PresenceEventUserStatusChanged    PresenceEventType = "user-status-changed"    // any other change of the user status
+	PresenceEventPlayerJoinedParty    PresenceEventType = "player-joined-party"    // player joined the game party
+	PresenceEventPlayerExitedParty    PresenceEventType = "player-exited-party"    // player left the game party
+	PresenceEventPlayerRemoved        PresenceEventType = "player-removed"         // party leader removed the player from the game party
+	PresenceEventPartyInviteSent      PresenceEventType = "party-invite-sent"      // party leader invited the player to the game party
+	PresenceEventPartyInviteAccepted  PresenceEventType = "party-invite-accepted"  // player accepted the invitation to the game party
+	PresenceEventPartyInviteRejected  PresenceEventType = "party-invite-rejected"  // player rejected the invitation to the game party
+	PresenceEventPartyEnded           PresenceEventType = "party-ended"            // game party is over
+	PresenceEventServerShuttingDown   PresenceEventType = "server-shutting-down"   // last event on every stream before the server stops
+	PresenceEventPartyLeaderChanged   PresenceEventType = "party-leader-changed"   // leadership handed over or the leader left and a player was promoted
+	PresenceEventPartyInviteExpired   PresenceEventType = "party-invite-expired"   // invitation was not answered within the invite TTL
+	PresenceEventPartyInviteCancelled PresenceEventType = "party-invite-cancelled" // party leader cancelled the invitation
+	PresenceEventPartyJoinRequested   PresenceEventType = "party-join-requested"   // user asked the party leader for an invitation
+	PresenceEventReadyCheckStarted    PresenceEventType = "ready-check-started"    // party leader started a ready-check
+	PresenceEventReadyCheckResponded  PresenceEventType = "ready-check-responded"  // player answered the ready-check
+	PresenceEventPartyStatusChanged   PresenceEventType = "party-status-changed"   // party moved between lobby, ready-check and match
+)
+
+// event pushed to the real time streams
+type PresenceEvent struct {
+	Type         PresenceEventType `json:"type"`
+	ActorUserId  string            `json:"actorUserId"`            // user whose action or status change caused the event
+	TargetUserId string            `json:"targetUserId,omitempty"` // user the action was applied to, if not the actor
+	PartyId      string            `json:"partyId,omitempty"`      // game party the event belongs to, if any
+	OldStatus    string            `json:"oldStatus,omitempty"`    // user status or player status before the event
+	NewStatus    string            `json:"newStatus,omitempty"`    // user status or player status after the event
+	Timestamp    time.Time         `json:"timestamp"`
+}
+
+func (e *PresenceEvent) EventType() string {
+	return string(e.Type)
+}
+
+// anything published on the event bus. Streams switch on the concrete type to tell the payloads apart
+type Event interface {
+	EventType() string
+}
+
+// chat message sent in a game party. Only published on the chat topic of the party
+type ChatMessageEvent struct {
+	Message *ChatMessage
+}
+
+func (e *ChatMessageEvent) EventType() string {
+	return "party-chat-message"
+}
+
+// direct message sent by or to the user. Only published on the message topic of the sender and the recipient
+type DirectMessageEvent struct {
+	Message *DirectMessage
+}
+
+func (e *DirectMessageEvent) EventType() string {
+	return "direct-message"
+}
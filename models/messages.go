@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// 1:1 message between friends. Stored until read, so that offline friends get it on their next login
+type DirectMessage struct {
+	MessageId   string    `bson:"_id" json:"messageId"`
+	SenderId    string    `bson:"senderId" json:"senderId"`
+	RecipientId string    `bson:"recipientId" json:"recipientId"`
+	Text        string    `bson:"text" json:"text"`
+	SentAt      time.Time `bson:"sentAt" json:"sentAt"`
+	Delivered   bool      `bson:"delivered" json:"delivered"` // pushed on a real time stream of the recipient
+	Read        bool      `bson:"read" json:"read"`           // marked read by the recipient
+}
+
+type SendDirectMessageRequestData struct {
+	UserId   string `json:"userId"`
+	FriendId string `json:"friendId"`
+	Text     string `json:"text"`
+}
+
+type SendDirectMessageResponseData struct {
+	Success bool           `json:"success"`
+	Message *DirectMessage `json:"message,omitempty"`
+	Errors  []string       `json:"errors,omitempty"`
+}
+
+type GetDirectMessagesResponseData struct {
+	Success  bool             `json:"success"`
+	Messages []*DirectMessage `json:"messages"`         // newest first
+	Before   *time.Time       `json:"before,omitempty"` // pass as before to get the next, older page. Not set on the last page
+	Errors   []string         `json:"errors,omitempty"`
+}
+
+type MarkDirectMessagesReadRequestData struct {
+	UserId   string `json:"userId"`
+	FriendId string `json:"friendId"`
+}
+
+type MarkDirectMessagesReadResponseData struct {
+	Success bool     `json:"success"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+type GetUnreadDirectMessagesResponseData struct {
+	Success bool           `json:"success"`
+	Unread  map[string]int `json:"unread"` // unread messages per friend
+	Total   int            `json:"total"`
+	Errors  []string       `json:"errors,omitempty"`
+}
@@ -8,46 +8,91 @@ import (
 type GamePartyStatus string
 
 const (
-	GamePartyStatusUndefined GamePartyStatus = "undefined"
-	GamePartyStatusOver      GamePartyStatus = "over"
-	GamePartyStatusActive    GamePartyStatus = "active"
+	GamePartyStatusUndefined  GamePartyStatus = "undefined"
+	GamePartyStatusOver       GamePartyStatus = "over"
+	GamePartyStatusActive     GamePartyStatus = "active"      // lobby. Players can join and the leader can start a ready-check
+	GamePartyStatusReadyCheck GamePartyStatus = "ready-check" // joined players are answering the leader's ready-check
+	GamePartyStatusInMatch    GamePartyStatus = "in-match"    // match is running until the leader finishes it
+)
+
+// answer of a joined player to the ready-check
+type ReadyCheckResponse string
+
+const (
+	ReadyCheckResponseReady    ReadyCheckResponse = "ready"
+	ReadyCheckResponseNotReady ReadyCheckResponse = "not-ready"
+)
+
+// latest ready-check of the party. Kept once it is over, to know who was ready for the match
+type ReadyCheck struct {
+	StartedBy string                        `bson:"startedBy" json:"startedBy"` // leader who started the ready-check
+	StartedAt time.Time                     `bson:"startedAt" json:"startedAt"`
+	ExpiresAt time.Time                     `bson:"expiresAt" json:"expiresAt"` // the match starts at this time even if not every player is ready
+	Responses map[string]ReadyCheckResponse `bson:"responses" json:"responses"`
+}
+
+// who can join the game party without being invited
+type GamePartyVisibility string
+
+const (
+	GamePartyVisibilityInviteOnly GamePartyVisibility = "invite-only" // only players who accepted an invitation can join
+	GamePartyVisibilityFriends    GamePartyVisibility = "friends"     // friends of the leader can also join directly
+	GamePartyVisibilityOpen       GamePartyVisibility = "open"        // anyone with the join code can also join directly
 )
 
 type GamePartyPlayerStatus string
 
 const (
 	PlayerStatusUndefined GamePartyPlayerStatus = "undefined"
-	PlayerInvitedStatus   GamePartyPlayerStatus = "invited"  // users who are invited to the party. They can later accept/reject the invitation
-	PlayerAcceptedStatus  GamePartyPlayerStatus = "accepted" // users who have accepted the invitation. They can later join the party
-	PlayerRejectedStatus  GamePartyPlayerStatus = "rejected" // users who have rejected the invitation. Can be invited again
-	PlayerJoinedStatus    GamePartyPlayerStatus = "joined"   // users who have joined the party
-	PlayerExitedStatus    GamePartyPlayerStatus = "exited"   // players who have left the party. Can be invited again
-	PlayerRemovedStatus   GamePartyPlayerStatus = "removed"  // players who have been removed from the game party. Can be invited again
+	PlayerInvitedStatus   GamePartyPlayerStatus = "invited"   // users who are invited to the party. They can later accept/reject the invitation
+	PlayerAcceptedStatus  GamePartyPlayerStatus = "accepted"  // users who have accepted the invitation. They can later join the party
+	PlayerRejectedStatus  GamePartyPlayerStatus = "rejected"  // users who have rejected the invitation. Can be invited again
+	PlayerJoinedStatus    GamePartyPlayerStatus = "joined"    // users who have joined the party
+	PlayerExitedStatus    GamePartyPlayerStatus = "exited"    // players who have left the party. Can be invited again
+	PlayerRemovedStatus   GamePartyPlayerStatus = "removed"   // players who have been removed from the game party. Can be invited again
+	PlayerExpiredStatus   GamePartyPlayerStatus = "expired"   // users who did not answer the invitation within the invite TTL. Can be invited again
+	PlayerCancelledStatus GamePartyPlayerStatus = "cancelled" // users whose invitation was cancelled by the party leader. Can be invited again
 )
 
 type GameServer struct {
-	Parties map[string]*GameParty
-	Mutex   sync.Mutex
+	Parties     map[string]*GameParty
+	UserParties map[string]string         // party every user is currently in, as the leader or a joined player. A user is in at most one party
+	JoinCodes   map[string]string         // party of every join code of the open parties
+	ChatHistory map[string][]*ChatMessage // latest chat messages of every party, oldest first. Replayed to players opening the chat
+	Mutex       sync.Mutex
 }
 
 type GameParty struct {
-	PartyId               string                           `bson:"_id" json:"partyId"`         // unique game party identifier
-	CreatedBy             string                           `bson:"createdBy" json:"createdBy"` // user that created the party
-	StartTime             time.Time                        `bson:"startTime" json:"startTime"` // time when party was created
-	Duration              time.Duration                    `bson:"duration" json:"duration"`   // duration for which the party is created
-	Status                GamePartyStatus                  `bson:"status" json:"status"`       // status of the game party
-	Players               map[string]GamePartyPlayerStatus `bson:"players" json:"players"`
-	PlayerStatusUpdateMsg chan string                      `json:"playerStatusUpdateMsg"`
+	PartyId    string                           `bson:"_id" json:"partyId"`                 // unique game party identifier
+	CreatedBy  string                           `bson:"createdBy" json:"createdBy"`         // user that created the party
+	StartTime  time.Time                        `bson:"startTime" json:"startTime"`         // time when party was created
+	Duration   time.Duration                    `bson:"duration" json:"duration"`           // duration for which the party is created
+	Status     GamePartyStatus                  `bson:"status" json:"status"`               // status of the game party
+	Capacity   int                              `bson:"capacity" json:"capacity"`           // most players the party can have, leader included. 0 for parties created before capacities existed
+	Leader     string                           `bson:"leader" json:"leader"`               // current party leader. Empty for parties created before leadership could be handed over, CreatedBy leads those
+	Visibility GamePartyVisibility              `bson:"visibility" json:"visibility"`       // empty for parties created before visibilities existed, those are invite-only
+	JoinCode   string                           `bson:"joinCode" json:"joinCode,omitempty"` // short code open parties can be joined with
+	Players    map[string]GamePartyPlayerStatus `bson:"players" json:"players"`
+	JoinedAt   map[string]time.Time             `bson:"joinedAt" json:"joinedAt,omitempty"`   // when the players with status 'joined' joined
+	InvitedBy  map[string]string                `bson:"invitedBy" json:"invitedBy,omitempty"` // leader who sent the latest invitation to the player
+	InvitedAt  map[string]time.Time             `bson:"invitedAt" json:"invitedAt,omitempty"` // when the latest invitation was sent to the player
+	// users who asked the leader for an invitation and when. Removed once they are invited
+	JoinRequests map[string]time.Time `bson:"joinRequests" json:"joinRequests,omitempty"`
+	ReadyCheck   *ReadyCheck          `bson:"readyCheck,omitempty" json:"readyCheck,omitempty"`
 }
 
 type CreateGamePartyRequestData struct {
-	UserId string `json:"userId"` // unique identifier of the user creating the party
+	UserId     string              `json:"userId"`               // unique identifier of the user creating the party
+	Duration   string              `json:"duration,omitempty"`   // optional. e.g. "45m", "2h". Default duration is used if empty
+	Capacity   int                 `json:"capacity,omitempty"`   // optional. most players the party can have, leader included. Max party size is used if 0
+	Visibility GamePartyVisibility `json:"visibility,omitempty"` // optional. invite-only if empty
 }
 
 type CreateGamePartyResponseData struct {
-	Success bool     `json:"success"`
-	PartyId string   `json:"partyId,omitempty"`
-	Errors  []string `json:"errors,omitempty"`
+	Success  bool     `json:"success"`
+	PartyId  string   `json:"partyId,omitempty"`
+	JoinCode string   `json:"joinCode,omitempty"` // only for open parties. Can be shared with anyone
+	Errors   []string `json:"errors,omitempty"`
 }
 
 type InviteToGamePartyRequestData struct {
@@ -72,9 +117,21 @@ type HandleGamePartyInviteResponseData struct {
 	Errors  []string `json:"errors,omitempty"`
 }
 
+type CancelGamePartyInviteRequestData struct {
+	PartyId   string   `json:"partyId"`
+	UserId    string   `json:"userId"`    // party leader
+	FriendIds []string `json:"friendIds"` // users with status 'invited' or 'accepted'
+}
+
+type CancelGamePartyInviteResponseData struct {
+	Success bool     `json:"success"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
 type JoinGamePartyRequestData struct {
-	PartyId string `json:"partyId"`
-	UserId  string `json:"userId"`
+	PartyId  string `json:"partyId"` // optional when the join code is sent
+	UserId   string `json:"userId"`
+	JoinCode string `json:"joinCode,omitempty"` // to join an open party without an invitation
 }
 
 type JoinGamePartyResponseData struct {
@@ -102,3 +159,134 @@ type RemoveUsersFromGamePartyResponseData struct {
 	Success bool     `json:"success"`
 	Errors  []string `json:"errors,omitempty"`
 }
+
+type ExtendGamePartyRequestData struct {
+	PartyId  string `json:"partyId"`
+	UserId   string `json:"userId"`   // user Id of the user who created the party
+	ExtendBy string `json:"extendBy"` // added to the current duration. e.g. "30m"
+}
+
+type ExtendGamePartyResponseData struct {
+	Success bool       `json:"success"`
+	EndTime *time.Time `json:"endTime,omitempty"` // time at which the party will now end
+	Errors  []string   `json:"errors,omitempty"`
+}
+
+type EndGamePartyRequestData struct {
+	PartyId string `json:"partyId"`
+	UserId  string `json:"userId"` // user Id of the user who created the party
+}
+
+type EndGamePartyResponseData struct {
+	Success bool     `json:"success"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+type TransferGamePartyLeaderRequestData struct {
+	PartyId     string `json:"partyId"`
+	UserId      string `json:"userId"`      // current party leader
+	NewLeaderId string `json:"newLeaderId"` // player with status 'joined'
+}
+
+type TransferGamePartyLeaderResponseData struct {
+	Success bool     `json:"success"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// game party as returned by the read APIs
+type GamePartyDetails struct {
+	PartyId       string                           `json:"partyId"`
+	CreatedBy     string                           `json:"createdBy"`
+	Leader        string                           `json:"leader"`
+	StartTime     time.Time                        `json:"startTime"`
+	EndTime       time.Time                        `json:"endTime"`
+	RemainingTime string                           `json:"remainingTime"` // e.g. "1h29m10s". "0s" once the party is over
+	Status        GamePartyStatus                  `json:"status"`
+	Visibility    GamePartyVisibility              `json:"visibility"`
+	JoinCode      string                           `json:"joinCode,omitempty"` // only shown to the leader and the joined players
+	Capacity      int                              `json:"capacity"`           // most players the party can have, leader included
+	Players       map[string]GamePartyPlayerStatus `json:"players"`
+	JoinRequests  map[string]time.Time             `json:"joinRequests,omitempty"` // only shown to the leader
+	ReadyCheck    *ReadyCheck                      `json:"readyCheck,omitempty"`
+}
+
+// party an in-game friend is in, as seen by the user listing the friends
+type FriendParty struct {
+	PartyId        string              `json:"partyId"`
+	Leader         string              `json:"leader"`
+	Visibility     GamePartyVisibility `json:"visibility"`
+	Capacity       int                 `json:"capacity"`
+	SlotsLeft      int                 `json:"slotsLeft"`      // slots not held by joined players or pending invitations
+	Joinable       bool                `json:"joinable"`       // the user can join the party right away
+	CanRequestJoin bool                `json:"canRequestJoin"` // the user can ask the leader for an invitation
+}
+
+type RequestJoinGamePartyRequestData struct {
+	PartyId string `json:"partyId"`
+	UserId  string `json:"userId"`
+}
+
+type RequestJoinGamePartyResponseData struct {
+	Success bool     `json:"success"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+type StartReadyCheckRequestData struct {
+	PartyId string `json:"partyId"`
+	UserId  string `json:"userId"`
+}
+
+type StartReadyCheckResponseData struct {
+	Success   bool       `json:"success"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Errors    []string   `json:"errors,omitempty"`
+}
+
+type RespondReadyCheckRequestData struct {
+	PartyId  string             `json:"partyId"`
+	UserId   string             `json:"userId"`
+	Response ReadyCheckResponse `json:"response"` // ready or not-ready
+}
+
+type RespondReadyCheckResponseData struct {
+	Success bool     `json:"success"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+type FinishMatchRequestData struct {
+	PartyId string `json:"partyId"`
+	UserId  string `json:"userId"`
+}
+
+type FinishMatchResponseData struct {
+	Success bool     `json:"success"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+type GetGamePartyResponseData struct {
+	Success bool              `json:"success"`
+	Party   *GamePartyDetails `json:"party,omitempty"`
+	Errors  []string          `json:"errors,omitempty"`
+}
+
+type ListGamePartiesResponseData struct {
+	Success bool                `json:"success"`
+	Parties []*GamePartyDetails `json:"parties"`
+	Errors  []string            `json:"errors,omitempty"`
+}
+
+// invitation to a game party the user has not accepted or rejected yet
+type PartyInvitation struct {
+	PartyId      string    `json:"partyId"`
+	InvitedBy    string    `json:"invitedBy"`
+	InvitedAt    time.Time `json:"invitedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`    // invitation can no longer be accepted after this
+	Leader       string    `json:"leader"`       // current party leader
+	PartyEndTime time.Time `json:"partyEndTime"` // invitation is dropped once the party is over
+}
+
+type GetPartyInvitationsResponseData struct {
+	Success     bool               `json:"success"`
+	Invitations []*PartyInvitation `json:"invitations"`
+	Errors      []string           `json:"errors,omitempty"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type UserStatus string
 
 const (
@@ -10,14 +12,11 @@ const (
 	// UserStatusSuspended UserStatus = "suspended"
 )
 
-// to keep track of users who are listening for player online update
-type UserServer struct {
-	UserDetails map[string]*UserDetails
-}
-
-type UserDetails struct {
-	// FriendId              string      `json:"friendId"`
-	FriendOnlineUpdateMsg chan string `json:"playerStatusUpdateMsg"`
+// session issued on login. The token has to be sent as "Authorization: Bearer <token>" on every other call
+type Session struct {
+	Token     string    `json:"token"`
+	UserId    string    `json:"userId"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
 
 type UserCredentials struct {
@@ -32,6 +31,24 @@ type User struct {
 	Email  string     `bson:"email" json:"email"`
 	Level  string     `bson:"level" json:"level"`             // this field can be used on UI side to show some kind of symbol with the player
 	Status UserStatus `bson:"status" json:"status,omitempty"` // user status
+	// last time the user logged in or sent a heartbeat. Users not seen for longer than the heartbeat timeout are marked offline
+	LastSeen time.Time `bson:"lastSeen" json:"lastSeen"`
+	// party the friend is in. Only sent in the friends list, when asked for
+	Party *FriendParty `bson:"-" json:"party,omitempty"`
+}
+
+type UserRegisterRequestData struct {
+	UserId   string `json:"userId"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Level    string `json:"level"`
+}
+
+type UserRegisterResponseData struct {
+	Success     bool     `json:"success"`
+	Errors      []string `json:"errors,omitempty"`
+	UserDetails *User    `json:"userDetails,omitempty"`
 }
 
 type UserLogInRequestData struct {
@@ -43,10 +60,17 @@ type UserLogInResponseData struct {
 	Success     bool     `json:"success"`
 	Errors      []string `json:"errors,omitempty"`
 	UserDetails *User    `json:"userDetails,omitempty"`
+	Session     *Session `json:"session,omitempty"`
+}
+
+type UserHeartbeatResponseData struct {
+	Success bool     `json:"success"`
+	Errors  []string `json:"errors,omitempty"`
 }
 
 type UserLogOutRequestData struct {
-	UserId string `json:"userId"`
+	UserId       string `json:"userId"`
+	SessionToken string `json:"-"` // session being logged out, taken from the authenticated request
 }
 
 type UserLogOutResponseData struct {
@@ -0,0 +1,754 @@
+package mongodao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// inMemoryDAO is a MongoDAO backed by plain maps instead of MongoDB.
+// It mirrors the behaviour of mongoDAO (including its error messages) so that
+// services can be run locally or exercised without a live mongo instance.
+type inMemoryDAO struct {
+	mutex       sync.RWMutex
+	users       map[string]*models.User            // users collection, keyed by userId
+	userCreds   map[string]*models.UserCredentials // usercreds collection, keyed by userId
+	friends     map[string]*models.Friends         // friends collection, keyed by document Id
+	gameParties map[string]*models.GameParty       // gameparty collection, keyed by partyId
+	partyChat   map[string][]*models.ChatMessage   // partychat collection, grouped by partyId in insertion order
+	directMsgs  []*models.DirectMessage            // directmessages collection in insertion order
+}
+
+func InitInMemoryDao() MongoDAO {
+	mongodaoOnce.Do(func() {
+		mongoDAOStruct = NewInMemoryDao()
+	})
+	return mongoDAOStruct
+}
+
+// empty in-memory database that is not shared. InitInMemoryDao has to be used by the server
+func NewInMemoryDao() MongoDAO {
+	return &inMemoryDAO{
+		users:       make(map[string]*models.User),
+		userCreds:   make(map[string]*models.UserCredentials),
+		friends:     make(map[string]*models.Friends),
+		gameParties: make(map[string]*models.GameParty),
+		partyChat:   make(map[string][]*models.ChatMessage),
+	}
+}
+
+// copies are handed out so that callers cannot mutate the stored documents,
+// the same way every mongo read returns a freshly decoded document
+func copyUser(user *models.User) *models.User {
+	userCopy := *user
+	return &userCopy
+}
+
+func copyGameParty(gameParty *models.GameParty) *models.GameParty {
+	gamePartyCopy := &models.GameParty{
+		PartyId:    gameParty.PartyId,
+		CreatedBy:  gameParty.CreatedBy,
+		StartTime:  gameParty.StartTime,
+		Duration:   gameParty.Duration,
+		Status:     gameParty.Status,
+		Capacity:   gameParty.Capacity,
+		Leader:     gameParty.Leader,
+		Visibility: gameParty.Visibility,
+		JoinCode:   gameParty.JoinCode,
+	}
+	if gameParty.Players != nil {
+		gamePartyCopy.Players = make(map[string]models.GamePartyPlayerStatus, len(gameParty.Players))
+		for playerId, playerStatus := range gameParty.Players {
+			gamePartyCopy.Players[playerId] = playerStatus
+		}
+	}
+	if gameParty.JoinedAt != nil {
+		gamePartyCopy.JoinedAt = make(map[string]time.Time, len(gameParty.JoinedAt))
+		for playerId, joinedAt := range gameParty.JoinedAt {
+			gamePartyCopy.JoinedAt[playerId] = joinedAt
+		}
+	}
+	if gameParty.InvitedBy != nil {
+		gamePartyCopy.InvitedBy = make(map[string]string, len(gameParty.InvitedBy))
+		for playerId, inviterId := range gameParty.InvitedBy {
+			gamePartyCopy.InvitedBy[playerId] = inviterId
+		}
+	}
+	if gameParty.InvitedAt != nil {
+		gamePartyCopy.InvitedAt = make(map[string]time.Time, len(gameParty.InvitedAt))
+		for playerId, invitedAt := range gameParty.InvitedAt {
+			gamePartyCopy.InvitedAt[playerId] = invitedAt
+		}
+	}
+	gamePartyCopy.ReadyCheck = copyReadyCheck(gameParty.ReadyCheck)
+	if gameParty.JoinRequests != nil {
+		gamePartyCopy.JoinRequests = make(map[string]time.Time, len(gameParty.JoinRequests))
+		for userId, requestedAt := range gameParty.JoinRequests {
+			gamePartyCopy.JoinRequests[userId] = requestedAt
+		}
+	}
+	return gamePartyCopy
+}
+
+func (m *inMemoryDAO) InsertPartyChatMessage(ctx context.Context, chatMessage *models.ChatMessage) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	chatMessageCopy := *chatMessage
+	m.partyChat[chatMessage.PartyId] = append(m.partyChat[chatMessage.PartyId], &chatMessageCopy)
+	return nil
+}
+
+func (m *inMemoryDAO) FetchPartyChatMessages(ctx context.Context, partyId string, limit int) ([]*models.ChatMessage, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	stored := m.partyChat[partyId]
+	if len(stored) > limit {
+		stored = stored[len(stored)-limit:]
+	}
+
+	var chatMessages []*models.ChatMessage
+	for _, chatMessage := range stored {
+		chatMessageCopy := *chatMessage
+		chatMessages = append(chatMessages, &chatMessageCopy)
+	}
+	return chatMessages, nil
+}
+
+func (m *inMemoryDAO) InsertDirectMessage(ctx context.Context, directMessage *models.DirectMessage) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	directMessageCopy := *directMessage
+	m.directMsgs = append(m.directMsgs, &directMessageCopy)
+	return nil
+}
+
+func (m *inMemoryDAO) FetchDirectMessages(ctx context.Context, userId string, friendId string, before time.Time, limit int) ([]*models.DirectMessage, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	// newest first, same as the mongo sort
+	var directMessages []*models.DirectMessage
+	for i := len(m.directMsgs) - 1; i >= 0 && len(directMessages) < limit; i-- {
+		directMessage := m.directMsgs[i]
+		between := (directMessage.SenderId == userId && directMessage.RecipientId == friendId) ||
+			(directMessage.SenderId == friendId && directMessage.RecipientId == userId)
+		if between && directMessage.SentAt.Before(before) {
+			directMessageCopy := *directMessage
+			directMessages = append(directMessages, &directMessageCopy)
+		}
+	}
+	return directMessages, nil
+}
+
+func (m *inMemoryDAO) FetchUndeliveredDirectMessages(ctx context.Context, recipientId string) ([]*models.DirectMessage, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var directMessages []*models.DirectMessage
+	for _, directMessage := range m.directMsgs {
+		if directMessage.RecipientId == recipientId && !directMessage.Delivered {
+			directMessageCopy := *directMessage
+			directMessages = append(directMessages, &directMessageCopy)
+		}
+	}
+	return directMessages, nil
+}
+
+func (m *inMemoryDAO) MarkDirectMessagesDelivered(ctx context.Context, messageIds []string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delivered := make(map[string]bool, len(messageIds))
+	for _, messageId := range messageIds {
+		delivered[messageId] = true
+	}
+	for _, directMessage := range m.directMsgs {
+		if delivered[directMessage.MessageId] {
+			directMessage.Delivered = true
+		}
+	}
+	return nil
+}
+
+func (m *inMemoryDAO) MarkDirectMessagesRead(ctx context.Context, recipientId string, senderId string, until time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, directMessage := range m.directMsgs {
+		if directMessage.RecipientId == recipientId && directMessage.SenderId == senderId && !directMessage.SentAt.After(until) {
+			directMessage.Read = true
+		}
+	}
+	return nil
+}
+
+func (m *inMemoryDAO) CountUnreadDirectMessages(ctx context.Context, recipientId string) (map[string]int, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	unread := make(map[string]int)
+	for _, directMessage := range m.directMsgs {
+		if directMessage.RecipientId == recipientId && !directMessage.Read {
+			unread[directMessage.SenderId]++
+		}
+	}
+	return unread, nil
+}
+
+func copyReadyCheck(readyCheck *models.ReadyCheck) *models.ReadyCheck {
+	if readyCheck == nil {
+		return nil
+	}
+	readyCheckCopy := &models.ReadyCheck{
+		StartedBy: readyCheck.StartedBy,
+		StartedAt: readyCheck.StartedAt,
+		ExpiresAt: readyCheck.ExpiresAt,
+	}
+	if readyCheck.Responses != nil {
+		readyCheckCopy.Responses = make(map[string]models.ReadyCheckResponse, len(readyCheck.Responses))
+		for playerId, response := range readyCheck.Responses {
+			readyCheckCopy.Responses[playerId] = response
+		}
+	}
+	return readyCheckCopy
+}
+
+func (m *inMemoryDAO) CheckUserCreds(ctx context.Context, userId string, pwd string) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	userCreds, ok := m.userCreds[userId]
+	if !ok {
+		return false, errors.New("incorrect user credentials")
+	}
+
+	matched, needsUpgrade := verifyPassword(userCreds.Password, pwd)
+	if !matched {
+		return false, errors.New("incorrect user credentials")
+	}
+
+	if needsUpgrade {
+		hashedPwd, err := hashPassword(pwd)
+		if err != nil {
+			fmt.Println("Failed to hash password of a legacy usercreds record.", err)
+			return true, nil
+		}
+		userCreds.Password = hashedPwd
+	}
+
+	return true, nil
+}
+
+// store a new user and its hashed password
+func (m *inMemoryDAO) CreateUser(ctx context.Context, user *models.User, pwd string) error {
+
+	hashedPwd, err := hashPassword(pwd)
+	if err != nil {
+		fmt.Println("Failed to hash the password.", err)
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.users[user.ID]; ok {
+		return ErrUserAlreadyExists
+	}
+	if _, ok := m.userCreds[user.ID]; ok {
+		return ErrUserAlreadyExists
+	}
+
+	m.users[user.ID] = copyUser(user)
+	m.userCreds[user.ID] = &models.UserCredentials{
+		ID:       user.ID,
+		Password: hashedPwd,
+	}
+	return nil
+}
+
+func (m *inMemoryDAO) GetUserFriends(ctx context.Context, userId string) ([]*models.Friends, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var friends []*models.Friends
+	for _, friend := range m.friends {
+		if friend.UserId == userId && friend.Status == models.FriendshipStatusAccepted {
+			friendCopy := *friend
+			friends = append(friends, &friendCopy)
+		}
+	}
+
+	if len(friends) == 0 {
+		fmt.Println("No friends found")
+		return nil, errors.New("no friends found")
+	}
+
+	return friends, nil
+}
+
+// Get all users who have accepted the friend request
+func (m *inMemoryDAO) GetFriendsDetails(ctx context.Context, userId string) ([]*models.User, error) {
+	m.mutex.RLock()
+	var friendIds []string
+	for _, friend := range m.friends {
+		if friend.UserId == userId && friend.Status == models.FriendshipStatusAccepted {
+			friendIds = append(friendIds, friend.FriendId)
+		}
+	}
+	m.mutex.RUnlock()
+
+	if len(friendIds) == 0 {
+		fmt.Println("No friends found")
+		return nil, nil
+	}
+
+	return m.GetUserDetails(ctx, friendIds)
+}
+
+// Get user details
+func (m *inMemoryDAO) GetUserDetails(ctx context.Context, userIds []string) ([]*models.User, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	// same as mongo's $in, repeated userIds match a single document
+	found := make(map[string]bool)
+	var users []*models.User
+	for _, userId := range userIds {
+		if user, ok := m.users[userId]; ok && !found[userId] {
+			found[userId] = true
+			users = append(users, copyUser(user))
+		}
+	}
+
+	if len(users) == 0 {
+		fmt.Println("No users found")
+		return nil, errors.New("no users found")
+	} else if len(users) != len(userIds) {
+		logrus.WithFields(logrus.Fields{
+			literals.LLRequestedUserIds: userIds,
+			literals.LLUsersFound:       (fmt.Sprintf("%+v", users)),
+		}).Error("some users not found")
+
+		return nil, errors.New("some users not found")
+	}
+	return users, nil
+}
+
+func (m *inMemoryDAO) UpdateUsersStatus(ctx context.Context, userIds []string, status models.UserStatus) (*mongo.UpdateResult, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	result := &mongo.UpdateResult{}
+	updated := make(map[string]bool)
+	for _, userId := range userIds {
+		user, ok := m.users[userId]
+		if !ok || updated[userId] {
+			continue
+		}
+		updated[userId] = true
+		result.MatchedCount++
+		// mongo does not count a document as modified if the value is unchanged
+		if user.Status != status {
+			user.Status = status
+			result.ModifiedCount++
+		}
+	}
+	return result, nil
+}
+
+func (m *inMemoryDAO) UpdateUserLastSeen(ctx context.Context, userId string, lastSeen time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	user, ok := m.users[userId]
+	if !ok {
+		return errors.New("invalid userId")
+	}
+	user.LastSeen = lastSeen
+	return nil
+}
+
+// fetch users who are not offline but have not been seen since lastSeenBefore
+func (m *inMemoryDAO) FetchStaleOnlineUsers(ctx context.Context, lastSeenBefore time.Time) ([]*models.User, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var users []*models.User
+	for _, user := range m.users {
+		if (user.Status == models.UserStatusIdle || user.Status == models.UserStatusInGame) && user.LastSeen.Before(lastSeenBefore) {
+			users = append(users, copyUser(user))
+		}
+	}
+	return users, nil
+}
+
+// mark the users offline unless they were seen since lastSeenBefore. Returns the users actually marked offline
+func (m *inMemoryDAO) MarkStaleUsersOffline(ctx context.Context, userIds []string, lastSeenBefore time.Time) ([]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var offlineUserIds []string
+	for _, userId := range userIds {
+		user, ok := m.users[userId]
+		if !ok || (user.Status != models.UserStatusIdle && user.Status != models.UserStatusInGame) || !user.LastSeen.Before(lastSeenBefore) {
+			continue
+		}
+		user.Status = models.UserStatusOffline
+		offlineUserIds = append(offlineUserIds, userId)
+	}
+	return offlineUserIds, nil
+}
+
+func (m *inMemoryDAO) StoreFriendRequests(ctx context.Context, userId string, friendIds []string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	time := time.Now()
+
+	for _, friendId := range friendIds {
+		docs := []*models.Friends{
+			{
+				Id:          uuid.NewString(),
+				UserId:      userId,
+				FriendId:    friendId,
+				Status:      models.FriendshipStatusPending,
+				RequestedBy: userId,
+				RequestedOn: time,
+			},
+			{
+				Id:          uuid.NewString(),
+				UserId:      friendId,
+				FriendId:    userId,
+				Status:      models.FriendshipStatusPending,
+				RequestedBy: userId,
+				RequestedOn: time,
+			},
+		}
+		for _, doc := range docs {
+			m.friends[doc.Id] = doc
+		}
+	}
+	return nil
+}
+
+// returns true if the friends document links userId and one of the friendIds in either direction
+func isFriendshipBetween(friend *models.Friends, userId string, friendIds []string) bool {
+	for _, friendId := range friendIds {
+		if (friend.UserId == userId && friend.FriendId == friendId) || (friend.UserId == friendId && friend.FriendId == userId) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *inMemoryDAO) UpdateFriendRequestsStatus(ctx context.Context, userId string, friendIds []string, status models.FriendRequestStatus) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, friend := range m.friends {
+		if isFriendshipBetween(friend, userId, friendIds) {
+			friend.Status = status
+		}
+	}
+	return nil
+}
+
+func (m *inMemoryDAO) RemoveFriends(ctx context.Context, userId string, friendIds []string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for id, friend := range m.friends {
+		if isFriendshipBetween(friend, userId, friendIds) {
+			delete(m.friends, id)
+		}
+	}
+	return nil
+}
+
+func (m *inMemoryDAO) UpdateGamePartyStatus(ctx context.Context, partyIds []string, status models.GamePartyStatus) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, partyId := range partyIds {
+		if gameParty, ok := m.gameParties[partyId]; ok {
+			gameParty.Status = status
+		}
+	}
+	return nil
+}
+
+func (m *inMemoryDAO) StartGamePartyReadyCheck(ctx context.Context, partyId string, readyCheck *models.ReadyCheck) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	gameParty, ok := m.gameParties[partyId]
+	if !ok {
+		return nil
+	}
+	gameParty.Status = models.GamePartyStatusReadyCheck
+	gameParty.ReadyCheck = copyReadyCheck(readyCheck)
+	return nil
+}
+
+func (m *inMemoryDAO) UpdateReadyCheckResponse(ctx context.Context, partyId string, userId string, response models.ReadyCheckResponse) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	gameParty, ok := m.gameParties[partyId]
+	if !ok || gameParty.ReadyCheck == nil {
+		return nil
+	}
+	if gameParty.ReadyCheck.Responses == nil {
+		gameParty.ReadyCheck.Responses = make(map[string]models.ReadyCheckResponse)
+	}
+	gameParty.ReadyCheck.Responses[userId] = response
+	return nil
+}
+
+func (m *inMemoryDAO) FetchGamePartiesToBeEnded(ctx context.Context, now time.Time) ([]*models.GameParty, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var gameParties []*models.GameParty
+	for _, gameParty := range m.gameParties {
+		if gameParty.Status != models.GamePartyStatusOver && gameParty.StartTime.Add(gameParty.Duration).Before(now) {
+			gameParties = append(gameParties, copyGameParty(gameParty))
+		}
+	}
+
+	if len(gameParties) == 0 {
+		fmt.Println("No active game parties that should have ended")
+		return nil, nil
+	}
+
+	return gameParties, nil
+}
+
+func (m *inMemoryDAO) GetGameParty(ctx context.Context, partyId string) (*models.GameParty, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	gameParty, ok := m.gameParties[partyId]
+	if !ok {
+		return nil, nil
+	}
+	return copyGameParty(gameParty), nil
+}
+
+func (m *inMemoryDAO) UpdateGamePartyDuration(ctx context.Context, partyId string, duration time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	gameParty, ok := m.gameParties[partyId]
+	if !ok {
+		return errors.New("game party not found")
+	}
+	gameParty.Duration = duration
+	return nil
+}
+
+func (m *inMemoryDAO) FetchActiveGameParties(ctx context.Context) ([]*models.GameParty, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var gameParties []*models.GameParty
+	for _, gameParty := range m.gameParties {
+		if gameParty.Status != models.GamePartyStatusOver {
+			gameParties = append(gameParties, copyGameParty(gameParty))
+		}
+	}
+
+	if len(gameParties) == 0 {
+		fmt.Println("No active game parties found")
+		return nil, nil
+	}
+
+	return gameParties, nil
+}
+
+func (m *inMemoryDAO) CreateGameParty(ctx context.Context, gameParty *models.GameParty) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.gameParties[gameParty.PartyId]; ok {
+		fmt.Printf("failed to insert game party in DB. Err: duplicate partyId %v\n", gameParty.PartyId)
+		return errors.New("game party " + gameParty.PartyId + " already exists")
+	}
+
+	// players are not stored on creation, same as the mongo document
+	m.gameParties[gameParty.PartyId] = &models.GameParty{
+		PartyId:    gameParty.PartyId,
+		CreatedBy:  gameParty.CreatedBy,
+		StartTime:  gameParty.StartTime,
+		Duration:   gameParty.Duration,
+		Status:     gameParty.Status,
+		Capacity:   gameParty.Capacity,
+		Leader:     gameParty.Leader,
+		Visibility: gameParty.Visibility,
+		JoinCode:   gameParty.JoinCode,
+	}
+	return nil
+}
+
+// check if all friendsIDs are friends of the user
+func (m *inMemoryDAO) CheckFriendship(ctx context.Context, userId string, friendIds []string) (bool, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	requested := make(map[string]bool)
+	for _, friendId := range friendIds {
+		requested[friendId] = true
+	}
+
+	var friends []*models.Friends
+	for _, friend := range m.friends {
+		if friend.UserId == userId && friend.Status == models.FriendshipStatusAccepted && requested[friend.FriendId] {
+			friends = append(friends, friend)
+		}
+	}
+
+	if len(friends) == 0 {
+		fmt.Println("No friends found")
+		return false, errors.New("no friends found")
+	} else if len(friends) != len(friendIds) {
+		logrus.WithFields(logrus.Fields{
+			literals.LLRequestedFriendIds: friendIds,
+			literals.LLFriendsFound:       (fmt.Sprintf("%+v", friends)),
+		}).Error("requested users are not friends")
+		return false, errors.New("requested users are not friends")
+	}
+
+	return true, nil
+}
+
+func (m *inMemoryDAO) AddInviteesToGameParty(ctx context.Context, partyId string, inviterId string, newInvitees []string, invitedAt time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	gameParty, ok := m.gameParties[partyId]
+	if !ok {
+		return nil
+	}
+
+	if gameParty.Players == nil {
+		gameParty.Players = make(map[string]models.GamePartyPlayerStatus)
+	}
+	if gameParty.InvitedBy == nil {
+		gameParty.InvitedBy = make(map[string]string)
+	}
+	if gameParty.InvitedAt == nil {
+		gameParty.InvitedAt = make(map[string]time.Time)
+	}
+	for _, invitee := range newInvitees {
+		gameParty.Players[invitee] = models.PlayerInvitedStatus
+		gameParty.InvitedBy[invitee] = inviterId
+		gameParty.InvitedAt[invitee] = invitedAt
+		delete(gameParty.JoinRequests, invitee)
+	}
+	return nil
+}
+
+func (m *inMemoryDAO) AddJoinRequestToGameParty(ctx context.Context, partyId string, userId string, requestedAt time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	gameParty, ok := m.gameParties[partyId]
+	if !ok {
+		return nil
+	}
+
+	if gameParty.JoinRequests == nil {
+		gameParty.JoinRequests = make(map[string]time.Time)
+	}
+	gameParty.JoinRequests[userId] = requestedAt
+	return nil
+}
+
+func (m *inMemoryDAO) AddPlayerToGameParty(ctx context.Context, partyId string, userId string, playerStatus models.GamePartyPlayerStatus) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	gameParty, ok := m.gameParties[partyId]
+	if !ok {
+		return nil
+	}
+
+	if gameParty.Players == nil {
+		gameParty.Players = make(map[string]models.GamePartyPlayerStatus)
+	}
+	gameParty.Players[userId] = playerStatus
+	return nil
+}
+
+func (m *inMemoryDAO) UpdatePlayersDecisionForGameParty(ctx context.Context, partyId string, userIds []string, playerStatus models.GamePartyPlayerStatus) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	gameParty, ok := m.gameParties[partyId]
+	if !ok {
+		return nil
+	}
+
+	// the party is only updated if every user is already a player, as with the $exists filter in mongo
+	for _, userId := range userIds {
+		if _, ok := gameParty.Players[userId]; !ok {
+			return nil
+		}
+	}
+
+	for _, userId := range userIds {
+		gameParty.Players[userId] = playerStatus
+	}
+	return nil
+}
+
+func (m *inMemoryDAO) UpdatePlayerJoinedAt(ctx context.Context, partyId string, userId string, joinedAt time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	gameParty, ok := m.gameParties[partyId]
+	if !ok {
+		return nil
+	}
+	if gameParty.JoinedAt == nil {
+		gameParty.JoinedAt = make(map[string]time.Time)
+	}
+	gameParty.JoinedAt[userId] = joinedAt
+	return nil
+}
+
+func (m *inMemoryDAO) UpdateGamePartyLeader(ctx context.Context, partyId string, oldLeaderId string, oldLeaderStatus models.GamePartyPlayerStatus, newLeaderId string, changedAt time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	gameParty, ok := m.gameParties[partyId]
+	if !ok {
+		return errors.New("game party not found")
+	}
+
+	gameParty.Leader = newLeaderId
+	if gameParty.Players == nil {
+		gameParty.Players = make(map[string]models.GamePartyPlayerStatus)
+	}
+	if gameParty.JoinedAt == nil {
+		gameParty.JoinedAt = make(map[string]time.Time)
+	}
+	gameParty.Players[oldLeaderId] = oldLeaderStatus
+	if oldLeaderStatus == models.PlayerJoinedStatus {
+		gameParty.JoinedAt[oldLeaderId] = changedAt
+	}
+	delete(gameParty.Players, newLeaderId)
+	delete(gameParty.JoinedAt, newLeaderId)
+	return nil
+}
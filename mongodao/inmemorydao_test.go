@@ -0,0 +1,138 @@
+package mongodao
+
+import (
+	"context"
+	"errors"
+	"lite-social-presence-system/models"
+	"testing"
+	"time"
+)
+
+func TestInMemoryGamePartyCopies(t *testing.T) {
+	ctx := context.TODO()
+	dao := NewInMemoryDao()
+
+	if err := dao.CreateGameParty(ctx, &models.GameParty{PartyId: "party1", CreatedBy: "leader", StartTime: time.Now(), Duration: time.Hour}); err != nil {
+		t.Fatalf("CreateGameParty: %v", err)
+	}
+	if err := dao.AddPlayerToGameParty(ctx, "party1", "u1", models.PlayerInvitedStatus); err != nil {
+		t.Fatalf("AddPlayerToGameParty: %v", err)
+	}
+
+	gameParty, err := dao.GetGameParty(ctx, "party1")
+	if err != nil || gameParty == nil {
+		t.Fatalf("GetGameParty = %v, %v", gameParty, err)
+	}
+	// callers own what they read, like a decoded mongo document
+	gameParty.Players["u1"] = models.PlayerJoinedStatus
+	gameParty.Duration = 0
+
+	storedParty, err := dao.GetGameParty(ctx, "party1")
+	if err != nil {
+		t.Fatalf("GetGameParty: %v", err)
+	}
+	if storedParty.Players["u1"] != models.PlayerInvitedStatus || storedParty.Duration != time.Hour {
+		t.Errorf("stored party changed through a read copy: %+v", storedParty)
+	}
+
+	if gameParty, err := dao.GetGameParty(ctx, "unknown"); gameParty != nil || err != nil {
+		t.Errorf("GetGameParty of an unknown party = %v, %v. want nil, nil", gameParty, err)
+	}
+}
+
+func TestInMemoryUserCreds(t *testing.T) {
+	ctx := context.TODO()
+
+	tests := []struct {
+		name          string
+		storedPwd     string // plaintext legacy record when set, registered through CreateUser otherwise
+		loginPwd      string
+		wantMatched   bool
+		wantUpgraded  bool
+		wantPlaintext bool
+	}{
+		{name: "registered user with the right password", loginPwd: "secret", wantMatched: true},
+		{name: "registered user with a wrong password", loginPwd: "wrong"},
+		{name: "legacy user with the right password is upgraded", storedPwd: "secret", loginPwd: "secret", wantMatched: true, wantUpgraded: true},
+		{name: "legacy user with a wrong password is not upgraded", storedPwd: "secret", loginPwd: "wrong", wantPlaintext: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dao := NewInMemoryDao().(*inMemoryDAO)
+			if tt.storedPwd != "" {
+				dao.users["u1"] = &models.User{ID: "u1"}
+				dao.userCreds["u1"] = &models.UserCredentials{ID: "u1", Password: tt.storedPwd}
+			} else if err := dao.CreateUser(ctx, &models.User{ID: "u1"}, "secret"); err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+
+			if tt.storedPwd == "" && !isPasswordHashed(dao.userCreds["u1"].Password) {
+				t.Fatalf("password stored as plaintext on register")
+			}
+
+			matched, err := dao.CheckUserCreds(ctx, "u1", tt.loginPwd)
+			if matched != tt.wantMatched || (err == nil) != tt.wantMatched {
+				t.Fatalf("CheckUserCreds = %v, %v. want matched %v", matched, err, tt.wantMatched)
+			}
+
+			storedPwd := dao.userCreds["u1"].Password
+			if tt.wantUpgraded && !isPasswordHashed(storedPwd) {
+				t.Errorf("legacy password was not hashed after login")
+			}
+			if tt.wantPlaintext && storedPwd != tt.storedPwd {
+				t.Errorf("legacy password changed after a failed login")
+			}
+		})
+	}
+}
+
+func TestInMemoryCreateUserTwice(t *testing.T) {
+	ctx := context.TODO()
+	dao := NewInMemoryDao()
+
+	if err := dao.CreateUser(ctx, &models.User{ID: "u1"}, "secret"); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := dao.CreateUser(ctx, &models.User{ID: "u1"}, "other"); !errors.Is(err, ErrUserAlreadyExists) {
+		t.Fatalf("CreateUser of an existing user = %v, want %v", err, ErrUserAlreadyExists)
+	}
+}
+
+func TestInMemoryMarkStaleUsersOffline(t *testing.T) {
+	ctx := context.TODO()
+	cutoff := time.Now()
+
+	tests := []struct {
+		name        string
+		status      models.UserStatus
+		lastSeen    time.Time
+		wantOffline bool
+	}{
+		{name: "idle user not seen since the cutoff", status: models.UserStatusIdle, lastSeen: cutoff.Add(-time.Minute), wantOffline: true},
+		{name: "in-game user not seen since the cutoff", status: models.UserStatusInGame, lastSeen: cutoff.Add(-time.Minute), wantOffline: true},
+		{name: "heartbeat arrived after the user was fetched", status: models.UserStatusIdle, lastSeen: cutoff.Add(time.Second)},
+		{name: "user already offline", status: models.UserStatusOffline, lastSeen: cutoff.Add(-time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dao := NewInMemoryDao().(*inMemoryDAO)
+			dao.users["u1"] = &models.User{ID: "u1", Status: tt.status, LastSeen: tt.lastSeen}
+
+			offlineUserIds, err := dao.MarkStaleUsersOffline(ctx, []string{"u1"}, cutoff)
+			if err != nil {
+				t.Fatalf("MarkStaleUsersOffline: %v", err)
+			}
+			if (len(offlineUserIds) == 1) != tt.wantOffline {
+				t.Fatalf("MarkStaleUsersOffline = %v, want offline %v", offlineUserIds, tt.wantOffline)
+			}
+			if tt.wantOffline && dao.users["u1"].Status != models.UserStatusOffline {
+				t.Errorf("status = %v, want %v", dao.users["u1"].Status, models.UserStatusOffline)
+			}
+			if !tt.wantOffline && dao.users["u1"].Status != tt.status {
+				t.Errorf("status = %v, want unchanged %v", dao.users["u1"].Status, tt.status)
+			}
+		})
+	}
+}
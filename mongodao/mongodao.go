@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
+	"slices"
 	"sync"
 	"time"
 
@@ -19,10 +20,14 @@ import (
 
 type MongoDAO interface {
 	CheckUserCreds(ctx context.Context, userId string, pwd string) (bool, error)
+	CreateUser(ctx context.Context, user *models.User, pwd string) error
 
 	GetFriendsDetails(ctx context.Context, userId string) ([]*models.User, error)
 	GetUserDetails(ctx context.Context, userIds []string) ([]*models.User, error)
 	UpdateUsersStatus(ctx context.Context, userIds []string, status models.UserStatus) (*mongo.UpdateResult, error)
+	UpdateUserLastSeen(ctx context.Context, userId string, lastSeen time.Time) error
+	FetchStaleOnlineUsers(ctx context.Context, lastSeenBefore time.Time) ([]*models.User, error)
+	MarkStaleUsersOffline(ctx context.Context, userIds []string, lastSeenBefore time.Time) ([]string, error)
 	StoreFriendRequests(ctx context.Context, userId string, friendIds []string) error
 	UpdateFriendRequestsStatus(ctx context.Context, userId string, friendIds []string, status models.FriendRequestStatus) error
 	RemoveFriends(ctx context.Context, userId string, friendIds []string) error
@@ -30,11 +35,30 @@ type MongoDAO interface {
 
 	// game party
 	FetchActiveGameParties(ctx context.Context) ([]*models.GameParty, error)
+	FetchGamePartiesToBeEnded(ctx context.Context, now time.Time) ([]*models.GameParty, error)
+	GetGameParty(ctx context.Context, partyId string) (*models.GameParty, error)
 	UpdateGamePartyStatus(ctx context.Context, partyIds []string, status models.GamePartyStatus) error
+	StartGamePartyReadyCheck(ctx context.Context, partyId string, readyCheck *models.ReadyCheck) error
+	UpdateReadyCheckResponse(ctx context.Context, partyId string, userId string, response models.ReadyCheckResponse) error
+
+	InsertPartyChatMessage(ctx context.Context, chatMessage *models.ChatMessage) error
+	FetchPartyChatMessages(ctx context.Context, partyId string, limit int) ([]*models.ChatMessage, error)
+
+	InsertDirectMessage(ctx context.Context, directMessage *models.DirectMessage) error
+	FetchDirectMessages(ctx context.Context, userId string, friendId string, before time.Time, limit int) ([]*models.DirectMessage, error)
+	FetchUndeliveredDirectMessages(ctx context.Context, recipientId string) ([]*models.DirectMessage, error)
+	MarkDirectMessagesDelivered(ctx context.Context, messageIds []string) error
+	MarkDirectMessagesRead(ctx context.Context, recipientId string, senderId string, until time.Time) error
+	CountUnreadDirectMessages(ctx context.Context, recipientId string) (map[string]int, error)
+	UpdateGamePartyDuration(ctx context.Context, partyId string, duration time.Duration) error
 	CreateGameParty(ctx context.Context, gamePary *models.GameParty) error
 	CheckFriendship(ctx context.Context, userId string, friendIds []string) (bool, error)
-	AddInviteesToGameParty(ctx context.Context, partyId string, newInvitees []string) error
+	AddInviteesToGameParty(ctx context.Context, partyId string, inviterId string, newInvitees []string, invitedAt time.Time) error
 	UpdatePlayersDecisionForGameParty(ctx context.Context, partyId string, userIds []string, playerStatus models.GamePartyPlayerStatus) error
+	AddPlayerToGameParty(ctx context.Context, partyId string, userId string, playerStatus models.GamePartyPlayerStatus) error
+	AddJoinRequestToGameParty(ctx context.Context, partyId string, userId string, requestedAt time.Time) error
+	UpdatePlayerJoinedAt(ctx context.Context, partyId string, userId string, joinedAt time.Time) error
+	UpdateGamePartyLeader(ctx context.Context, partyId string, oldLeaderId string, oldLeaderStatus models.GamePartyPlayerStatus, newLeaderId string, changedAt time.Time) error
 
 	// UpdatePlayerAndUserStatusForGameParty(ctx context.Context, partyId string, userId string, playerStatus models.GamePartyPlayerStatus, userStatus models.UserStatus) error
 	// obsolete
@@ -87,11 +111,18 @@ func Close(client *mongo.Client, ctx context.Context, cancel context.CancelFunc)
 	// to cancel context
 	defer cancel()
 
-	defer func() {
-		if err := client.Disconnect(ctx); err != nil {
-			panic(err)
-		}
-	}()
+	// ctx given by Connect expires after a while. Disconnect with a fresh one in that case
+	if ctx.Err() != nil {
+		var disconnectCancel context.CancelFunc
+		ctx, disconnectCancel = context.WithTimeout(context.Background(), 10*time.Second)
+		defer disconnectCancel()
+	}
+
+	if err := client.Disconnect(ctx); err != nil {
+		fmt.Println("Error disconnecting from mongoDB:", err)
+		return
+	}
+	fmt.Println("MongoDB disconnected")
 }
 
 // To ping the mongoDB
@@ -107,8 +138,7 @@ func Ping(client *mongo.Client, ctx context.Context) error {
 
 func (m mongoDAO) CheckUserCreds(ctx context.Context, userId string, pwd string) (bool, error) {
 	filter := bson.M{
-		literals.MongoID:       userId,
-		literals.MongoPassword: pwd,
+		literals.MongoID: userId,
 	}
 
 	var userCreds models.UserCredentials
@@ -123,9 +153,76 @@ func (m mongoDAO) CheckUserCreds(ctx context.Context, userId string, pwd string)
 		}
 	}
 
+	matched, needsUpgrade := verifyPassword(userCreds.Password, pwd)
+	if !matched {
+		return false, errors.New("incorrect user credentials")
+	}
+
+	if needsUpgrade {
+		// legacy plaintext record. Replace it with the hash now that the password is known to be correct
+		hashedPwd, err := hashPassword(pwd)
+		if err != nil {
+			fmt.Println("Failed to hash password of a legacy usercreds record.", err)
+			return true, nil
+		}
+
+		update := bson.M{
+			literals.MongoSet: bson.M{
+				literals.MongoPassword: hashedPwd,
+			},
+		}
+
+		result, err := m.databse.Collection(literals.UserCredsCollection).UpdateOne(ctx, filter, update)
+		if err != nil {
+			// login still succeeds, upgrade will be retried on the next login
+			fmt.Printf("Failed to upgrade legacy password in the usercreds collection. Err: %v\nUpdateResult: %v\n", err, result)
+		}
+	}
+
 	return true, nil
 }
 
+// store a new user in the users collection and its hashed password in the usercreds collection
+func (m mongoDAO) CreateUser(ctx context.Context, user *models.User, pwd string) error {
+
+	hashedPwd, err := hashPassword(pwd)
+	if err != nil {
+		fmt.Println("Failed to hash the password.", err)
+		return err
+	}
+
+	result, err := m.databse.Collection(literals.UsersCollection).InsertOne(ctx, user)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrUserAlreadyExists
+		}
+		fmt.Printf("failed to insert user in DB. Err: %v\nInsertOneResult: %v\n", err, result)
+		return err
+	}
+
+	userCreds := models.UserCredentials{
+		ID:       user.ID,
+		Password: hashedPwd,
+	}
+
+	result, err = m.databse.Collection(literals.UserCredsCollection).InsertOne(ctx, userCreds)
+	if err != nil {
+		fmt.Printf("failed to insert user creds in DB. Err: %v\nInsertOneResult: %v\n", err, result)
+
+		// do not leave a user behind who can never log in
+		_, deleteErr := m.databse.Collection(literals.UsersCollection).DeleteOne(ctx, bson.M{literals.MongoID: user.ID})
+		if deleteErr != nil {
+			fmt.Println("Failed to roll back the user document.", deleteErr)
+		}
+
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrUserAlreadyExists
+		}
+		return err
+	}
+	return nil
+}
+
 func (m mongoDAO) GetUserFriends(ctx context.Context, userId string) ([]*models.Friends, error) {
 
 	filter := bson.M{
@@ -255,6 +352,93 @@ func (m mongoDAO) UpdateUsersStatus(ctx context.Context, userIds []string, statu
 	return result, nil
 }
 
+func (m mongoDAO) UpdateUserLastSeen(ctx context.Context, userId string, lastSeen time.Time) error {
+
+	filter := bson.M{
+		literals.MongoID: userId,
+	}
+
+	update := bson.M{
+		literals.MongoSet: bson.M{
+			literals.MongoLastSeen: lastSeen,
+		},
+	}
+
+	result, err := m.databse.Collection(literals.UsersCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("Failed to update user last seen time in the users collection. Err: %v\nUpdateResult: %v\n", err, result)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("invalid userId")
+	}
+	return nil
+}
+
+// fetch users who are not offline but have not been seen since lastSeenBefore
+func (m mongoDAO) FetchStaleOnlineUsers(ctx context.Context, lastSeenBefore time.Time) ([]*models.User, error) {
+
+	filter := bson.M{
+		literals.MongoStatus: bson.M{literals.MongoIn: []models.UserStatus{models.UserStatusIdle, models.UserStatusInGame}},
+		literals.MongoOr: []bson.M{
+			{literals.MongoLastSeen: bson.M{literals.MongoLessThan: lastSeenBefore}},
+			{literals.MongoLastSeen: bson.M{literals.MongoExists: false}}, // users who logged in before heartbeats were recorded
+		},
+	}
+
+	cur, err := m.databse.Collection(literals.UsersCollection).Find(ctx, filter)
+	if err != nil {
+		fmt.Println("Error occurred while fetching stale users.", err)
+		return nil, err
+	}
+
+	var users []*models.User
+	for cur.Next(ctx) {
+		var user models.User
+		decodeErr := cur.Decode(&user)
+		if decodeErr != nil {
+			fmt.Println(decodeErr)
+			return nil, decodeErr
+		}
+		users = append(users, &user)
+	}
+
+	return users, nil
+}
+
+// mark the users offline unless they were seen since lastSeenBefore. Users who sent a heartbeat
+// after they were fetched as stale keep their status. Returns the users actually marked offline
+func (m mongoDAO) MarkStaleUsersOffline(ctx context.Context, userIds []string, lastSeenBefore time.Time) ([]string, error) {
+
+	update := bson.M{
+		literals.MongoSet: bson.M{
+			literals.MongoStatus: models.UserStatusOffline,
+		},
+	}
+
+	var offlineUserIds []string
+	for _, userId := range userIds {
+		filter := bson.M{
+			literals.MongoID:     userId,
+			literals.MongoStatus: bson.M{literals.MongoIn: []models.UserStatus{models.UserStatusIdle, models.UserStatusInGame}},
+			literals.MongoOr: []bson.M{
+				{literals.MongoLastSeen: bson.M{literals.MongoLessThan: lastSeenBefore}},
+				{literals.MongoLastSeen: bson.M{literals.MongoExists: false}},
+			},
+		}
+
+		result, err := m.databse.Collection(literals.UsersCollection).UpdateOne(ctx, filter, update)
+		if err != nil {
+			fmt.Printf("Failed to mark stale user offline in the users collection. Err: %v\nUpdateResult: %v\n", err, result)
+			return offlineUserIds, err
+		}
+		if result.ModifiedCount > 0 {
+			offlineUserIds = append(offlineUserIds, userId)
+		}
+	}
+	return offlineUserIds, nil
+}
+
 func (m mongoDAO) StoreFriendRequests(ctx context.Context, userId string, friendIds []string) error {
 
 	var docs []interface{}
@@ -400,10 +584,76 @@ func (m mongoDAO) UpdateGamePartyStatus(ctx context.Context, partyIds []string,
 	return nil
 }
 
+// moves the party to ready-check and replaces the previous ready-check
+func (m mongoDAO) StartGamePartyReadyCheck(ctx context.Context, partyId string, readyCheck *models.ReadyCheck) error {
+
+	filter := bson.M{
+		literals.MongoID: partyId,
+	}
+
+	update := bson.M{
+		literals.MongoSet: bson.M{
+			literals.MongoStatus:     models.GamePartyStatusReadyCheck,
+			literals.MongoReadyCheck: readyCheck,
+		},
+	}
+
+	result, err := m.databse.Collection(literals.GamePartyCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("Failed to start the ready-check of the game party in the DB. Err: %v\nUpdateResult: %v\n", err, result)
+		return err
+	}
+	return nil
+}
+
+func (m mongoDAO) UpdateReadyCheckResponse(ctx context.Context, partyId string, userId string, response models.ReadyCheckResponse) error {
+
+	filter := bson.M{
+		literals.MongoID: partyId,
+	}
+
+	update := bson.M{
+		literals.MongoSet: bson.M{
+			literals.MongoReadyCheckResponsesDotAccess + userId: response,
+		},
+	}
+
+	result, err := m.databse.Collection(literals.GamePartyCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("Failed to update the ready-check response in the DB. Err: %v\nUpdateResult: %v\n", err, result)
+		return err
+	}
+	return nil
+}
+
+func (m mongoDAO) UpdateGamePartyDuration(ctx context.Context, partyId string, duration time.Duration) error {
+
+	filter := bson.M{
+		literals.MongoID: partyId,
+	}
+
+	update := bson.M{
+		literals.MongoSet: bson.M{
+			literals.MongoDuration: duration,
+		},
+	}
+
+	result, err := m.databse.Collection(literals.GamePartyCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("Failed to update game party duration in DB. Err: %v\nUpdateResult: %v\n", err, result)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("game party not found")
+	}
+	return nil
+}
+
 func (m mongoDAO) FetchActiveGameParties(ctx context.Context) ([]*models.GameParty, error) {
 
 	filter := bson.M{
-		literals.MongoStatus: models.GamePartyStatusActive,
+		// lobby, ready-check and in-match parties are all running
+		literals.MongoStatus: bson.M{literals.MongoNotEqual: models.GamePartyStatusOver},
 	}
 	/*
 		// more safer query
@@ -450,11 +700,15 @@ func (m mongoDAO) FetchActiveGameParties(ctx context.Context) ([]*models.GamePar
 func (m mongoDAO) CreateGameParty(ctx context.Context, gameParty *models.GameParty) error {
 
 	docs := bson.M{
-		literals.MongoID:        gameParty.PartyId,
-		literals.MongoCreatedBy: gameParty.CreatedBy,
-		literals.MongoStartTime: gameParty.StartTime,
-		literals.MongoDuration:  gameParty.Duration,
-		literals.MongoStatus:    gameParty.Status,
+		literals.MongoID:         gameParty.PartyId,
+		literals.MongoCreatedBy:  gameParty.CreatedBy,
+		literals.MongoStartTime:  gameParty.StartTime,
+		literals.MongoDuration:   gameParty.Duration,
+		literals.MongoStatus:     gameParty.Status,
+		literals.MongoCapacity:   gameParty.Capacity,
+		literals.MongoLeader:     gameParty.Leader,
+		literals.MongoVisibility: gameParty.Visibility,
+		literals.MongoJoinCode:   gameParty.JoinCode,
 	}
 
 	result, err := m.databse.Collection(literals.GamePartyCollection).InsertOne(ctx, docs)
@@ -505,12 +759,17 @@ func (m mongoDAO) CheckFriendship(ctx context.Context, userId string, friendIds
 	return true, nil
 }
 
-func (m mongoDAO) AddInviteesToGameParty(ctx context.Context, partyId string, newInvitees []string) error {
+func (m mongoDAO) AddInviteesToGameParty(ctx context.Context, partyId string, inviterId string, newInvitees []string, invitedAt time.Time) error {
 
 	var updates bson.M = bson.M{}
+	var joinRequests bson.M = bson.M{}
 
 	for _, invitee := range newInvitees {
 		updates[literals.MongoPlayersDotAccess+invitee] = models.PlayerInvitedStatus
+		updates[literals.MongoInvitedByDotAccess+invitee] = inviterId
+		updates[literals.MongoInvitedAtDotAccess+invitee] = invitedAt
+		// the invitation answers the join request of the invitee
+		joinRequests[literals.MongoJoinRequestsDotAccess+invitee] = literals.EmptyString
 	}
 
 	filter := bson.M{
@@ -518,7 +777,8 @@ func (m mongoDAO) AddInviteesToGameParty(ctx context.Context, partyId string, ne
 	}
 
 	update := bson.M{
-		literals.MongoSet: updates,
+		literals.MongoSet:   updates,
+		literals.MongoUnset: joinRequests,
 	}
 
 	result, err := m.databse.Collection(literals.GamePartyCollection).UpdateMany(ctx, filter, update)
@@ -529,6 +789,47 @@ func (m mongoDAO) AddInviteesToGameParty(ctx context.Context, partyId string, ne
 	return nil
 }
 
+func (m mongoDAO) AddJoinRequestToGameParty(ctx context.Context, partyId string, userId string, requestedAt time.Time) error {
+
+	filter := bson.M{
+		literals.MongoID: partyId,
+	}
+
+	update := bson.M{
+		literals.MongoSet: bson.M{
+			literals.MongoJoinRequestsDotAccess + userId: requestedAt,
+		},
+	}
+
+	result, err := m.databse.Collection(literals.GamePartyCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("Failed to add join request to the game party in the DB. Err: %v\nUpdateResult: %v\n", err, result)
+		return err
+	}
+	return nil
+}
+
+// adds the user to the players of the party, without requiring an invitation
+func (m mongoDAO) AddPlayerToGameParty(ctx context.Context, partyId string, userId string, playerStatus models.GamePartyPlayerStatus) error {
+
+	filter := bson.M{
+		literals.MongoID: partyId,
+	}
+
+	update := bson.M{
+		literals.MongoSet: bson.M{
+			literals.MongoPlayersDotAccess + userId: playerStatus,
+		},
+	}
+
+	result, err := m.databse.Collection(literals.GamePartyCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("Failed to add player to the game party in the DB. Err: %v\nUpdateResult: %v\n", err, result)
+		return err
+	}
+	return nil
+}
+
 func (m mongoDAO) UpdatePlayersDecisionForGameParty(ctx context.Context, partyId string, userIds []string, playerStatus models.GamePartyPlayerStatus) error {
 
 	var updates bson.M = bson.M{}
@@ -557,6 +858,60 @@ func (m mongoDAO) UpdatePlayersDecisionForGameParty(ctx context.Context, partyId
 	return nil
 }
 
+func (m mongoDAO) UpdatePlayerJoinedAt(ctx context.Context, partyId string, userId string, joinedAt time.Time) error {
+
+	filter := bson.M{
+		literals.MongoID: partyId,
+	}
+
+	update := bson.M{
+		literals.MongoSet: bson.M{
+			literals.MongoJoinedAtDotAccess + userId: joinedAt,
+		},
+	}
+
+	result, err := m.databse.Collection(literals.GamePartyCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("Failed to update player joined time in the game party collection. Err: %v\nUpdateResult: %v\n", err, result)
+		return err
+	}
+	return nil
+}
+
+// new leader stops being a player. Old leader becomes a player with oldLeaderStatus
+func (m mongoDAO) UpdateGamePartyLeader(ctx context.Context, partyId string, oldLeaderId string, oldLeaderStatus models.GamePartyPlayerStatus, newLeaderId string, changedAt time.Time) error {
+
+	set := bson.M{
+		literals.MongoLeader:                         newLeaderId,
+		literals.MongoPlayersDotAccess + oldLeaderId: oldLeaderStatus,
+	}
+	if oldLeaderStatus == models.PlayerJoinedStatus {
+		set[literals.MongoJoinedAtDotAccess+oldLeaderId] = changedAt
+	}
+
+	filter := bson.M{
+		literals.MongoID: partyId,
+	}
+
+	update := bson.M{
+		literals.MongoSet: set,
+		literals.MongoUnset: bson.M{
+			literals.MongoPlayersDotAccess + newLeaderId:  literals.EmptyString,
+			literals.MongoJoinedAtDotAccess + newLeaderId: literals.EmptyString,
+		},
+	}
+
+	result, err := m.databse.Collection(literals.GamePartyCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("Failed to update game party leader in DB. Err: %v\nUpdateResult: %v\n", err, result)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("game party not found")
+	}
+	return nil
+}
+
 /*
 // In order to use transactions, you need a MongoDB replica set,
 func (m mongoDAO) UpdatePlayerAndUserStatusForGameParty(ctx context.Context, partyId string, userId string, playerStatus models.GamePartyPlayerStatus, userStatus models.UserStatus) error {
@@ -631,20 +986,23 @@ func (m mongoDAO) PullAndPushDataInGamePartyCollection(ctx context.Context, part
 }
 */
 
-/*
-// fetch game parties that should have ended.// will have (start time + duration) < curr time
-func (m mongoDAO) FetchGamePartiesToBeEnded(ctx context.Context) ([]*models.GameParty, error) {
-	filter := bson.D{
-		{literals.MongoStatus, models.GamePartyStatusActive},
-
-		{"$expr", bson.D{
-			{"$lt", bson.A{
-				bson.D{
-					{"$add", bson.A{"$startTime", "$duration"}},
-				},
-				time.Now(),
-			}},
-		}},
+// fetch active game parties that should have ended. will have (start time + duration) < now
+func (m mongoDAO) FetchGamePartiesToBeEnded(ctx context.Context, now time.Time) ([]*models.GameParty, error) {
+
+	// duration is stored in nanoseconds but $add on a date works in milliseconds
+	endTime := bson.M{
+		literals.MongoAdd: bson.A{
+			"$" + literals.MongoStartTime,
+			bson.M{literals.MongoDivide: bson.A{"$" + literals.MongoDuration, int64(time.Millisecond)}},
+		},
+	}
+
+	filter := bson.M{
+		// lobby, ready-check and in-match parties are all running
+		literals.MongoStatus: bson.M{literals.MongoNotEqual: models.GamePartyStatusOver},
+		literals.MongoExpr: bson.M{
+			literals.MongoLessThan: bson.A{endTime, now},
+		},
 	}
 
 	cur, err := m.databse.Collection(literals.GamePartyCollection).Find(ctx, filter)
@@ -671,4 +1029,200 @@ func (m mongoDAO) FetchGamePartiesToBeEnded(ctx context.Context) ([]*models.Game
 
 	return gameParties, nil
 }
-*/
+
+func (m mongoDAO) InsertPartyChatMessage(ctx context.Context, chatMessage *models.ChatMessage) error {
+
+	result, err := m.databse.Collection(literals.PartyChatCollection).InsertOne(ctx, chatMessage)
+	if err != nil {
+		fmt.Printf("failed to insert party chat message in DB. Err: %v\nInsertOneResult: %v\n", err, result)
+		return err
+	}
+	return nil
+}
+
+// latest limit chat messages of the party, oldest first
+func (m mongoDAO) FetchPartyChatMessages(ctx context.Context, partyId string, limit int) ([]*models.ChatMessage, error) {
+
+	filter := bson.M{
+		literals.MongoPartyId: partyId,
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: literals.MongoSentAt, Value: -1}}).SetLimit(int64(limit))
+
+	cur, err := m.databse.Collection(literals.PartyChatCollection).Find(ctx, filter, findOptions)
+	if err != nil {
+		fmt.Println("Error occurred while calling partychat collection.", err)
+		return nil, err
+	}
+
+	var chatMessages []*models.ChatMessage
+	for cur.Next(ctx) {
+		var chatMessage models.ChatMessage
+		decodeErr := cur.Decode(&chatMessage)
+		if decodeErr != nil {
+			fmt.Println("Failed to decode party chat message document.", decodeErr)
+			return nil, decodeErr
+		}
+		chatMessages = append(chatMessages, &chatMessage)
+	}
+
+	// newest first from the DB
+	slices.Reverse(chatMessages)
+	return chatMessages, nil
+}
+
+func (m mongoDAO) InsertDirectMessage(ctx context.Context, directMessage *models.DirectMessage) error {
+
+	result, err := m.databse.Collection(literals.DirectMessagesCollection).InsertOne(ctx, directMessage)
+	if err != nil {
+		fmt.Printf("failed to insert direct message in DB. Err: %v\nInsertOneResult: %v\n", err, result)
+		return err
+	}
+	return nil
+}
+
+// messages between the user and the friend sent before the given time, newest first
+func (m mongoDAO) FetchDirectMessages(ctx context.Context, userId string, friendId string, before time.Time, limit int) ([]*models.DirectMessage, error) {
+
+	filter := bson.M{
+		literals.MongoOr: []bson.M{
+			{literals.MongoSenderId: userId, literals.MongoRecipientId: friendId},
+			{literals.MongoSenderId: friendId, literals.MongoRecipientId: userId},
+		},
+		literals.MongoSentAt: bson.M{literals.MongoLessThan: before},
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: literals.MongoSentAt, Value: -1}}).SetLimit(int64(limit))
+
+	return m.findDirectMessages(ctx, filter, findOptions)
+}
+
+// messages not pushed to the recipient yet, oldest first
+func (m mongoDAO) FetchUndeliveredDirectMessages(ctx context.Context, recipientId string) ([]*models.DirectMessage, error) {
+
+	filter := bson.M{
+		literals.MongoRecipientId: recipientId,
+		literals.MongoDelivered:   false,
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: literals.MongoSentAt, Value: 1}})
+
+	return m.findDirectMessages(ctx, filter, findOptions)
+}
+
+func (m mongoDAO) findDirectMessages(ctx context.Context, filter bson.M, findOptions *options.FindOptions) ([]*models.DirectMessage, error) {
+
+	cur, err := m.databse.Collection(literals.DirectMessagesCollection).Find(ctx, filter, findOptions)
+	if err != nil {
+		fmt.Println("Error occurred while calling directmessages collection.", err)
+		return nil, err
+	}
+
+	var directMessages []*models.DirectMessage
+	for cur.Next(ctx) {
+		var directMessage models.DirectMessage
+		decodeErr := cur.Decode(&directMessage)
+		if decodeErr != nil {
+			fmt.Println("Failed to decode direct message document.", decodeErr)
+			return nil, decodeErr
+		}
+		directMessages = append(directMessages, &directMessage)
+	}
+	return directMessages, nil
+}
+
+func (m mongoDAO) MarkDirectMessagesDelivered(ctx context.Context, messageIds []string) error {
+
+	filter := bson.M{
+		literals.MongoID: bson.M{literals.MongoIn: messageIds},
+	}
+
+	update := bson.M{
+		literals.MongoSet: bson.M{
+			literals.MongoDelivered: true,
+		},
+	}
+
+	result, err := m.databse.Collection(literals.DirectMessagesCollection).UpdateMany(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("Failed to mark direct messages delivered in DB. Err: %v\nUpdateResult: %v\n", err, result)
+		return err
+	}
+	return nil
+}
+
+// every message the sender sent to the recipient until the given time is read
+func (m mongoDAO) MarkDirectMessagesRead(ctx context.Context, recipientId string, senderId string, until time.Time) error {
+
+	filter := bson.M{
+		literals.MongoRecipientId: recipientId,
+		literals.MongoSenderId:    senderId,
+		literals.MongoRead:        false,
+		literals.MongoSentAt:      bson.M{literals.MongoLessThanEqual: until},
+	}
+
+	update := bson.M{
+		literals.MongoSet: bson.M{
+			literals.MongoRead: true,
+		},
+	}
+
+	result, err := m.databse.Collection(literals.DirectMessagesCollection).UpdateMany(ctx, filter, update)
+	if err != nil {
+		fmt.Printf("Failed to mark direct messages read in DB. Err: %v\nUpdateResult: %v\n", err, result)
+		return err
+	}
+	return nil
+}
+
+// unread messages of the recipient per sender
+func (m mongoDAO) CountUnreadDirectMessages(ctx context.Context, recipientId string) (map[string]int, error) {
+
+	pipeline := bson.A{
+		bson.M{literals.MongoMatch: bson.M{
+			literals.MongoRecipientId: recipientId,
+			literals.MongoRead:        false,
+		}},
+		bson.M{literals.MongoGroup: bson.M{
+			literals.MongoID:    "$" + literals.MongoSenderId,
+			literals.MongoCount: bson.M{literals.MongoSum: 1},
+		}},
+	}
+
+	cur, err := m.databse.Collection(literals.DirectMessagesCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		fmt.Println("Error occurred while counting unread direct messages.", err)
+		return nil, err
+	}
+
+	unread := make(map[string]int)
+	for cur.Next(ctx) {
+		var count struct {
+			SenderId string `bson:"_id"`
+			Count    int    `bson:"count"`
+		}
+		decodeErr := cur.Decode(&count)
+		if decodeErr != nil {
+			fmt.Println("Failed to decode unread direct messages count.", decodeErr)
+			return nil, decodeErr
+		}
+		unread[count.SenderId] = count.Count
+	}
+	return unread, nil
+}
+
+// fetch a game party whatever its status. nil if there is no such party
+func (m mongoDAO) GetGameParty(ctx context.Context, partyId string) (*models.GameParty, error) {
+
+	filter := bson.M{
+		literals.MongoID: partyId,
+	}
+
+	var gameParty models.GameParty
+	err := m.databse.Collection(literals.GamePartyCollection).FindOne(ctx, filter).Decode(&gameParty)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		fmt.Println("Error occurred while fetching the game party.", err)
+		return nil, err
+	}
+
+	return &gameParty, nil
+}
@@ -0,0 +1,36 @@
+package mongodao
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUserAlreadyExists = errors.New("user already exists")
+
+// salted bcrypt hash of the password. The salt is stored as part of the hash
+func hashPassword(pwd string) (string, error) {
+	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(pwd), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPwd), nil
+}
+
+// records created before passwords were hashed hold the plaintext password
+func isPasswordHashed(storedPwd string) bool {
+	_, err := bcrypt.Cost([]byte(storedPwd))
+	return err == nil
+}
+
+// verifyPassword checks pwd against the stored password.
+// needsUpgrade is true when the stored password is a legacy plaintext record that matched
+func verifyPassword(storedPwd string, pwd string) (matched bool, needsUpgrade bool) {
+	if isPasswordHashed(storedPwd) {
+		return bcrypt.CompareHashAndPassword([]byte(storedPwd), []byte(pwd)) == nil, false
+	}
+	if storedPwd == pwd {
+		return true, true
+	}
+	return false, false
+}
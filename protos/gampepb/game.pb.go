@@ -9,6 +9,7 @@ package gampepb
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -20,31 +21,596 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type PresenceEventType int32
+
+const (
+	PresenceEventType_PRESENCE_EVENT_TYPE_UNSPECIFIED PresenceEventType = 0
+	PresenceEventType_USER_ONLINE                     PresenceEventType = 1  // user logged in or came back after missing heartbeats
+	PresenceEventType_USER_OFFLINE                    PresenceEventType = 2  // user logged out or stopped sending heartbeats
+	PresenceEventType_USER_STATUS_CHANGED             PresenceEventType = 3  // any other change of the user status
+	PresenceEventType_PLAYER_JOINED_PARTY             PresenceEventType = 4  // player joined the game party
+	PresenceEventType_PLAYER_EXITED_PARTY             PresenceEventType = 5  // player left the game party
+	PresenceEventType_PLAYER_REMOVED                  PresenceEventType = 6  // party leader removed the player from the game party
+	PresenceEventType_PARTY_INVITE_SENT               PresenceEventType = 7  // party leader invited the player to the game party
+	PresenceEventType_PARTY_INVITE_ACCEPTED           PresenceEventType = 8  // player accepted the invitation to the game party
+	PresenceEventType_PARTY_INVITE_REJECTED           PresenceEventType = 9  // player rejected the invitation to the game party
+	PresenceEventType_PARTY_ENDED                     PresenceEventType = 10 // game party is over
+	PresenceEventType_SERVER_SHUTTING_DOWN            PresenceEventType = 11 // last event on every stream before the server stops
+	PresenceEventType_PARTY_LEADER_CHANGED            PresenceEventType = 12 // leadership handed over or the leader left and a player was promoted
+	PresenceEventType_PARTY_INVITE_EXPIRED            PresenceEventType = 13 // invitation was not answered within the invite TTL
+	PresenceEventType_PARTY_INVITE_CANCELLED          PresenceEventType = 14 // party leader cancelled the invitation
+	PresenceEventType_PARTY_JOIN_REQUESTED            PresenceEventType = 15 // user asked the party leader for an invitation
+	PresenceEventType_READY_CHECK_STARTED             PresenceEventType = 16 // party leader started a ready-check
+	PresenceEventType_READY_CHECK_RESPONDED           PresenceEventType = 17 // player answered the ready-check
+	PresenceEventType_PARTY_STATUS_CHANGED            PresenceEventType = 18 // party moved between lobby, ready-check and match
+)
+
+// Enum value maps for PresenceEventType.
+var (
+	PresenceEventType_name = map[int32]string{
+		0:  "PRESENCE_EVENT_TYPE_UNSPECIFIED",
+		1:  "USER_ONLINE",
+		2:  "USER_OFFLINE",
+		3:  "USER_STATUS_CHANGED",
+		4:  "PLAYER_JOINED_PARTY",
+		5:  "PLAYER_EXITED_PARTY",
+		6:  "PLAYER_REMOVED",
+		7:  "PARTY_INVITE_SENT",
+		8:  "PARTY_INVITE_ACCEPTED",
+		9:  "PARTY_INVITE_REJECTED",
+		10: "PARTY_ENDED",
+		11: "SERVER_SHUTTING_DOWN",
+		12: "PARTY_LEADER_CHANGED",
+		13: "PARTY_INVITE_EXPIRED",
+		14: "PARTY_INVITE_CANCELLED",
+		15: "PARTY_JOIN_REQUESTED",
+		16: "READY_CHECK_STARTED",
+		17: "READY_CHECK_RESPONDED",
+		18: "PARTY_STATUS_CHANGED",
+	}
+	PresenceEventType_value = map[string]int32{
+		"PRESENCE_EVENT_TYPE_UNSPECIFIED": 0,
+		"USER_ONLINE":                     1,
+		"USER_OFFLINE":                    2,
+		"USER_STATUS_CHANGED":             3,
+		"PLAYER_JOINED_PARTY":             4,
+		"PLAYER_EXITED_PARTY":             5,
+		"PLAYER_REMOVED":                  6,
+		"PARTY_INVITE_SENT":               7,
+		"PARTY_INVITE_ACCEPTED":           8,
+		"PARTY_INVITE_REJECTED":           9,
+		"PARTY_ENDED":                     10,
+		"SERVER_SHUTTING_DOWN":            11,
+		"PARTY_LEADER_CHANGED":            12,
+		"PARTY_INVITE_EXPIRED":            13,
+		"PARTY_INVITE_CANCELLED":          14,
+		"PARTY_JOIN_REQUESTED":            15,
+		"READY_CHECK_STARTED":             16,
+		"READY_CHECK_RESPONDED":           17,
+		"PARTY_STATUS_CHANGED":            18,
+	}
+)
+
+func (x PresenceEventType) Enum() *PresenceEventType {
+	p := new(PresenceEventType)
+	*p = x
+	return p
+}
+
+func (x PresenceEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PresenceEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_game_proto_enumTypes[0].Descriptor()
+}
+
+func (PresenceEventType) Type() protoreflect.EnumType {
+	return &file_game_proto_enumTypes[0]
+}
+
+func (x PresenceEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PresenceEventType.Descriptor instead.
+func (PresenceEventType) EnumDescriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{0}
+}
+
+// structured event sent on the real time streams
+type PresenceEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type         PresenceEventType      `protobuf:"varint,1,opt,name=type,proto3,enum=protos.PresenceEventType" json:"type,omitempty"`
+	ActorUserId  string                 `protobuf:"bytes,2,opt,name=actorUserId,proto3" json:"actorUserId,omitempty"`   // user whose action or status change caused the event
+	TargetUserId string                 `protobuf:"bytes,3,opt,name=targetUserId,proto3" json:"targetUserId,omitempty"` // user the action was applied to, if not the actor
+	PartyId      string                 `protobuf:"bytes,4,opt,name=partyId,proto3" json:"partyId,omitempty"`           // game party the event belongs to, if any
+	OldStatus    string                 `protobuf:"bytes,5,opt,name=oldStatus,proto3" json:"oldStatus,omitempty"`       // user status or player status before the event
+	NewStatus    string                 `protobuf:"bytes,6,opt,name=newStatus,proto3" json:"newStatus,omitempty"`       // user status or player status after the event
+	Timestamp    *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`       // time at which the event happened
+}
+
+func (x *PresenceEvent) Reset() {
+	*x = PresenceEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_game_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PresenceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceEvent) ProtoMessage() {}
+
+func (x *PresenceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceEvent.ProtoReflect.Descriptor instead.
+func (*PresenceEvent) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PresenceEvent) GetType() PresenceEventType {
+	if x != nil {
+		return x.Type
+	}
+	return PresenceEventType_PRESENCE_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *PresenceEvent) GetActorUserId() string {
+	if x != nil {
+		return x.ActorUserId
+	}
+	return ""
+}
+
+func (x *PresenceEvent) GetTargetUserId() string {
+	if x != nil {
+		return x.TargetUserId
+	}
+	return ""
+}
+
+func (x *PresenceEvent) GetPartyId() string {
+	if x != nil {
+		return x.PartyId
+	}
+	return ""
+}
+
+func (x *PresenceEvent) GetOldStatus() string {
+	if x != nil {
+		return x.OldStatus
+	}
+	return ""
+}
+
+func (x *PresenceEvent) GetNewStatus() string {
+	if x != nil {
+		return x.NewStatus
+	}
+	return ""
+}
+
+func (x *PresenceEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
 type UserStatusChangeRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	UserId string `protobuf:"bytes,1,opt,name=userId,proto3" json:"userId,omitempty"`
+	UserId string `protobuf:"bytes,1,opt,name=userId,proto3" json:"userId,omitempty"`
+}
+
+func (x *UserStatusChangeRequest) Reset() {
+	*x = UserStatusChangeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_game_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UserStatusChangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserStatusChangeRequest) ProtoMessage() {}
+
+func (x *UserStatusChangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserStatusChangeRequest.ProtoReflect.Descriptor instead.
+func (*UserStatusChangeRequest) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UserStatusChangeRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type UserStatusChangeResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Deprecated: Marked as deprecated in game.proto.
+	Message string         `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"` // human readable text of the event. Use event instead
+	Event   *PresenceEvent `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+}
+
+func (x *UserStatusChangeResponse) Reset() {
+	*x = UserStatusChangeResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_game_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UserStatusChangeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserStatusChangeResponse) ProtoMessage() {}
+
+func (x *UserStatusChangeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserStatusChangeResponse.ProtoReflect.Descriptor instead.
+func (*UserStatusChangeResponse) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{2}
+}
+
+// Deprecated: Marked as deprecated in game.proto.
+func (x *UserStatusChangeResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *UserStatusChangeResponse) GetEvent() *PresenceEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+type PlayerInPartyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UserId  string `protobuf:"bytes,1,opt,name=userId,proto3" json:"userId,omitempty"`
+	PartyId string `protobuf:"bytes,2,opt,name=partyId,proto3" json:"partyId,omitempty"`
+}
+
+func (x *PlayerInPartyRequest) Reset() {
+	*x = PlayerInPartyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_game_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PlayerInPartyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayerInPartyRequest) ProtoMessage() {}
+
+func (x *PlayerInPartyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayerInPartyRequest.ProtoReflect.Descriptor instead.
+func (*PlayerInPartyRequest) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PlayerInPartyRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *PlayerInPartyRequest) GetPartyId() string {
+	if x != nil {
+		return x.PartyId
+	}
+	return ""
+}
+
+type PlayersInPartyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Deprecated: Marked as deprecated in game.proto.
+	Message string         `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"` // human readable text of the event. Use event instead
+	Event   *PresenceEvent `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+}
+
+func (x *PlayersInPartyResponse) Reset() {
+	*x = PlayersInPartyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_game_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PlayersInPartyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayersInPartyResponse) ProtoMessage() {}
+
+func (x *PlayersInPartyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayersInPartyResponse.ProtoReflect.Descriptor instead.
+func (*PlayersInPartyResponse) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{4}
+}
+
+// Deprecated: Marked as deprecated in game.proto.
+func (x *PlayersInPartyResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *PlayersInPartyResponse) GetEvent() *PresenceEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+type PartyInvitationsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UserId string `protobuf:"bytes,1,opt,name=userId,proto3" json:"userId,omitempty"`
+}
+
+func (x *PartyInvitationsRequest) Reset() {
+	*x = PartyInvitationsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_game_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PartyInvitationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartyInvitationsRequest) ProtoMessage() {}
+
+func (x *PartyInvitationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartyInvitationsRequest.ProtoReflect.Descriptor instead.
+func (*PartyInvitationsRequest) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PartyInvitationsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// pending invitations are sent first as PARTY_INVITE_SENT events, followed by the live updates: new invitations,
+// invitations accepted or rejected on another device, expired or cancelled invitations and invited parties that ended
+type PartyInvitationsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Event *PresenceEvent `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+}
+
+func (x *PartyInvitationsResponse) Reset() {
+	*x = PartyInvitationsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_game_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PartyInvitationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartyInvitationsResponse) ProtoMessage() {}
+
+func (x *PartyInvitationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartyInvitationsResponse.ProtoReflect.Descriptor instead.
+func (*PartyInvitationsResponse) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PartyInvitationsResponse) GetEvent() *PresenceEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+// sent by the client on the party chat. The first message opens the chat of partyId and may have no text
+type PartyChatRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PartyId string `protobuf:"bytes,1,opt,name=partyId,proto3" json:"partyId,omitempty"`
+	Text    string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+}
+
+func (x *PartyChatRequest) Reset() {
+	*x = PartyChatRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_game_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PartyChatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartyChatRequest) ProtoMessage() {}
+
+func (x *PartyChatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartyChatRequest.ProtoReflect.Descriptor instead.
+func (*PartyChatRequest) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PartyChatRequest) GetPartyId() string {
+	if x != nil {
+		return x.PartyId
+	}
+	return ""
+}
+
+func (x *PartyChatRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type PartyChatMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MessageId string                 `protobuf:"bytes,1,opt,name=messageId,proto3" json:"messageId,omitempty"`
+	PartyId   string                 `protobuf:"bytes,2,opt,name=partyId,proto3" json:"partyId,omitempty"`
+	UserId    string                 `protobuf:"bytes,3,opt,name=userId,proto3" json:"userId,omitempty"` // leader or joined player who sent the message
+	Text      string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	SentAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=sentAt,proto3" json:"sentAt,omitempty"`
 }
 
-func (x *UserStatusChangeRequest) Reset() {
-	*x = UserStatusChangeRequest{}
+func (x *PartyChatMessage) Reset() {
+	*x = PartyChatMessage{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_game_proto_msgTypes[0]
+		mi := &file_game_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *UserStatusChangeRequest) String() string {
+func (x *PartyChatMessage) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UserStatusChangeRequest) ProtoMessage() {}
+func (*PartyChatMessage) ProtoMessage() {}
 
-func (x *UserStatusChangeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_game_proto_msgTypes[0]
+func (x *PartyChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -55,43 +621,75 @@ func (x *UserStatusChangeRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UserStatusChangeRequest.ProtoReflect.Descriptor instead.
-func (*UserStatusChangeRequest) Descriptor() ([]byte, []int) {
-	return file_game_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use PartyChatMessage.ProtoReflect.Descriptor instead.
+func (*PartyChatMessage) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *UserStatusChangeRequest) GetUserId() string {
+func (x *PartyChatMessage) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *PartyChatMessage) GetPartyId() string {
+	if x != nil {
+		return x.PartyId
+	}
+	return ""
+}
+
+func (x *PartyChatMessage) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-type UserStatusChangeResponse struct {
+func (x *PartyChatMessage) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *PartyChatMessage) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+// the chat history is sent first, followed by every new message of the party.
+// error is set when a message of the client was not accepted, the chat stays open
+type PartyChatResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Message *PartyChatMessage `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Event   *PresenceEvent    `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"` // e.g. server shutting down
+	Error   string            `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 }
 
-func (x *UserStatusChangeResponse) Reset() {
-	*x = UserStatusChangeResponse{}
+func (x *PartyChatResponse) Reset() {
+	*x = PartyChatResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_game_proto_msgTypes[1]
+		mi := &file_game_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *UserStatusChangeResponse) String() string {
+func (x *PartyChatResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UserStatusChangeResponse) ProtoMessage() {}
+func (*PartyChatResponse) ProtoMessage() {}
 
-func (x *UserStatusChangeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_game_proto_msgTypes[1]
+func (x *PartyChatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -102,44 +700,57 @@ func (x *UserStatusChangeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UserStatusChangeResponse.ProtoReflect.Descriptor instead.
-func (*UserStatusChangeResponse) Descriptor() ([]byte, []int) {
-	return file_game_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use PartyChatResponse.ProtoReflect.Descriptor instead.
+func (*PartyChatResponse) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *UserStatusChangeResponse) GetMessage() string {
+func (x *PartyChatResponse) GetMessage() *PartyChatMessage {
 	if x != nil {
 		return x.Message
 	}
+	return nil
+}
+
+func (x *PartyChatResponse) GetEvent() *PresenceEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *PartyChatResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
 	return ""
 }
 
-type PlayerInPartyRequest struct {
+type DirectMessagesRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	UserId  string `protobuf:"bytes,1,opt,name=userId,proto3" json:"userId,omitempty"`
-	PartyId string `protobuf:"bytes,2,opt,name=partyId,proto3" json:"partyId,omitempty"`
+	UserId string `protobuf:"bytes,1,opt,name=userId,proto3" json:"userId,omitempty"`
 }
 
-func (x *PlayerInPartyRequest) Reset() {
-	*x = PlayerInPartyRequest{}
+func (x *DirectMessagesRequest) Reset() {
+	*x = DirectMessagesRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_game_proto_msgTypes[2]
+		mi := &file_game_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *PlayerInPartyRequest) String() string {
+func (x *DirectMessagesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PlayerInPartyRequest) ProtoMessage() {}
+func (*DirectMessagesRequest) ProtoMessage() {}
 
-func (x *PlayerInPartyRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_game_proto_msgTypes[2]
+func (x *DirectMessagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -150,50 +761,125 @@ func (x *PlayerInPartyRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PlayerInPartyRequest.ProtoReflect.Descriptor instead.
-func (*PlayerInPartyRequest) Descriptor() ([]byte, []int) {
-	return file_game_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use DirectMessagesRequest.ProtoReflect.Descriptor instead.
+func (*DirectMessagesRequest) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *PlayerInPartyRequest) GetUserId() string {
+func (x *DirectMessagesRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-func (x *PlayerInPartyRequest) GetPartyId() string {
+type DirectMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MessageId   string                 `protobuf:"bytes,1,opt,name=messageId,proto3" json:"messageId,omitempty"`
+	SenderId    string                 `protobuf:"bytes,2,opt,name=senderId,proto3" json:"senderId,omitempty"`
+	RecipientId string                 `protobuf:"bytes,3,opt,name=recipientId,proto3" json:"recipientId,omitempty"`
+	Text        string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	SentAt      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=sentAt,proto3" json:"sentAt,omitempty"`
+}
+
+func (x *DirectMessage) Reset() {
+	*x = DirectMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_game_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DirectMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DirectMessage) ProtoMessage() {}
+
+func (x *DirectMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DirectMessage.ProtoReflect.Descriptor instead.
+func (*DirectMessage) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DirectMessage) GetMessageId() string {
 	if x != nil {
-		return x.PartyId
+		return x.MessageId
 	}
 	return ""
 }
 
-type PlayersInPartyResponse struct {
+func (x *DirectMessage) GetSenderId() string {
+	if x != nil {
+		return x.SenderId
+	}
+	return ""
+}
+
+func (x *DirectMessage) GetRecipientId() string {
+	if x != nil {
+		return x.RecipientId
+	}
+	return ""
+}
+
+func (x *DirectMessage) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *DirectMessage) GetSentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SentAt
+	}
+	return nil
+}
+
+// messages sent to the user while offline are sent first, followed by every new message sent by or to the user.
+// A message sent by the user is streamed too, so that every device of the user sees it
+type DirectMessagesResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Message *DirectMessage `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Event   *PresenceEvent `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"` // e.g. server shutting down
 }
 
-func (x *PlayersInPartyResponse) Reset() {
-	*x = PlayersInPartyResponse{}
+func (x *DirectMessagesResponse) Reset() {
+	*x = DirectMessagesResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_game_proto_msgTypes[3]
+		mi := &file_game_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *PlayersInPartyResponse) String() string {
+func (x *DirectMessagesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PlayersInPartyResponse) ProtoMessage() {}
+func (*DirectMessagesResponse) ProtoMessage() {}
 
-func (x *PlayersInPartyResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_game_proto_msgTypes[3]
+func (x *DirectMessagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_game_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -204,51 +890,185 @@ func (x *PlayersInPartyResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PlayersInPartyResponse.ProtoReflect.Descriptor instead.
-func (*PlayersInPartyResponse) Descriptor() ([]byte, []int) {
-	return file_game_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use DirectMessagesResponse.ProtoReflect.Descriptor instead.
+func (*DirectMessagesResponse) Descriptor() ([]byte, []int) {
+	return file_game_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *PlayersInPartyResponse) GetMessage() string {
+func (x *DirectMessagesResponse) GetMessage() *DirectMessage {
 	if x != nil {
 		return x.Message
 	}
-	return ""
+	return nil
+}
+
+func (x *DirectMessagesResponse) GetEvent() *PresenceEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
 }
 
 var File_game_proto protoreflect.FileDescriptor
 
 var file_game_proto_rawDesc = []byte{
 	0x0a, 0x0a, 0x67, 0x61, 0x6d, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x06, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x73, 0x22, 0x31, 0x0a, 0x17, 0x55, 0x73, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74,
-	0x75, 0x73, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
-	0x16, 0x0a, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x22, 0x34, 0x0a, 0x18, 0x55, 0x73, 0x65, 0x72, 0x53,
-	0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x48, 0x0a,
-	0x14, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x6e, 0x50, 0x61, 0x72, 0x74, 0x79, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x18, 0x0a,
-	0x07, 0x70, 0x61, 0x72, 0x74, 0x79, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
-	0x70, 0x61, 0x72, 0x74, 0x79, 0x49, 0x64, 0x22, 0x32, 0x0a, 0x16, 0x50, 0x6c, 0x61, 0x79, 0x65,
-	0x72, 0x73, 0x49, 0x6e, 0x50, 0x61, 0x72, 0x74, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x32, 0xcc, 0x01, 0x0a, 0x0b,
-	0x55, 0x73, 0x65, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x5f, 0x0a, 0x16, 0x53,
-	0x74, 0x72, 0x65, 0x61, 0x6d, 0x55, 0x73, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43,
-	0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x1f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x55,
-	0x73, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
+	0x6f, 0x74, 0x6f, 0x73, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x94, 0x02, 0x0a, 0x0d, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e,
+	0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x2d, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50,
+	0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65,
+	0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x55,
+	0x73, 0x65, 0x72, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x61, 0x63, 0x74,
+	0x6f, 0x72, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x74, 0x61, 0x72, 0x67,
+	0x65, 0x74, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x55, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07,
+	0x70, 0x61, 0x72, 0x74, 0x79, 0x49, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70,
+	0x61, 0x72, 0x74, 0x79, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x6f, 0x6c, 0x64, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6f, 0x6c, 0x64, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x65, 0x77, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x65, 0x77, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x12, 0x38, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x31, 0x0a, 0x17,
 	0x55, 0x73, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x5c, 0x0a, 0x18,
-	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x4a, 0x6f, 0x69, 0x6e,
-	0x65, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x73, 0x2e, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x6e, 0x50, 0x61, 0x72, 0x74, 0x79, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
-	0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x49, 0x6e, 0x50, 0x61, 0x72, 0x74, 0x79, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x42, 0x0b, 0x5a, 0x09, 0x2e, 0x2f,
-	0x67, 0x61, 0x6d, 0x70, 0x65, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x22,
+	0x65, 0x0a, 0x18, 0x55, 0x73, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x07, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18, 0x01,
+	0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x73, 0x2e, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52,
+	0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x48, 0x0a, 0x14, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x49, 0x6e, 0x50, 0x61, 0x72, 0x74, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x72, 0x74, 0x79, 0x49,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61, 0x72, 0x74, 0x79, 0x49, 0x64,
+	0x22, 0x63, 0x0a, 0x16, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x49, 0x6e, 0x50, 0x61, 0x72,
+	0x74, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18, 0x01, 0x52,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
+	0x2e, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x05,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x31, 0x0a, 0x17, 0x50, 0x61, 0x72, 0x74, 0x79, 0x49, 0x6e,
+	0x76, 0x69, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x16, 0x0a, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x22, 0x47, 0x0a, 0x18, 0x50, 0x61, 0x72, 0x74,
+	0x79, 0x49, 0x6e, 0x76, 0x69, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x72, 0x65,
+	0x73, 0x65, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x22, 0x40, 0x0a, 0x10, 0x50, 0x61, 0x72, 0x74, 0x79, 0x43, 0x68, 0x61, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x72, 0x74, 0x79, 0x49, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61, 0x72, 0x74, 0x79, 0x49, 0x64, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74,
+	0x65, 0x78, 0x74, 0x22, 0xaa, 0x01, 0x0a, 0x10, 0x50, 0x61, 0x72, 0x74, 0x79, 0x43, 0x68, 0x61,
+	0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x72, 0x74, 0x79, 0x49,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61, 0x72, 0x74, 0x79, 0x49, 0x64,
+	0x12, 0x16, 0x0a, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x32, 0x0a, 0x06,
+	0x73, 0x65, 0x6e, 0x74, 0x41, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x06, 0x73, 0x65, 0x6e, 0x74, 0x41, 0x74,
+	0x22, 0x8a, 0x01, 0x0a, 0x11, 0x50, 0x61, 0x72, 0x74, 0x79, 0x43, 0x68, 0x61, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x32, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
+	0x2e, 0x50, 0x61, 0x72, 0x74, 0x79, 0x43, 0x68, 0x61, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x65, 0x76,
+	0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x73, 0x2e, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x52, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x2f, 0x0a,
+	0x15, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x22, 0xb3,
+	0x01, 0x0a, 0x0d, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x12, 0x1c, 0x0a, 0x09, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x49, 0x64, 0x12, 0x1a,
+	0x0a, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x49, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x72, 0x65,
+	0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x65, 0x78, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74,
+	0x12, 0x32, 0x0a, 0x06, 0x73, 0x65, 0x6e, 0x74, 0x41, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x06, 0x73, 0x65,
+	0x6e, 0x74, 0x41, 0x74, 0x22, 0x76, 0x0a, 0x16, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2f,
+	0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x4d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x2b, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x2a, 0xea, 0x03, 0x0a,
+	0x11, 0x50, 0x72, 0x65, 0x73, 0x65, 0x6e, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x23, 0x0a, 0x1f, 0x50, 0x52, 0x45, 0x53, 0x45, 0x4e, 0x43, 0x45, 0x5f, 0x45,
+	0x56, 0x45, 0x4e, 0x54, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43,
+	0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x53, 0x45, 0x52, 0x5f,
+	0x4f, 0x4e, 0x4c, 0x49, 0x4e, 0x45, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x55, 0x53, 0x45, 0x52,
+	0x5f, 0x4f, 0x46, 0x46, 0x4c, 0x49, 0x4e, 0x45, 0x10, 0x02, 0x12, 0x17, 0x0a, 0x13, 0x55, 0x53,
+	0x45, 0x52, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x43, 0x48, 0x41, 0x4e, 0x47, 0x45,
+	0x44, 0x10, 0x03, 0x12, 0x17, 0x0a, 0x13, 0x50, 0x4c, 0x41, 0x59, 0x45, 0x52, 0x5f, 0x4a, 0x4f,
+	0x49, 0x4e, 0x45, 0x44, 0x5f, 0x50, 0x41, 0x52, 0x54, 0x59, 0x10, 0x04, 0x12, 0x17, 0x0a, 0x13,
+	0x50, 0x4c, 0x41, 0x59, 0x45, 0x52, 0x5f, 0x45, 0x58, 0x49, 0x54, 0x45, 0x44, 0x5f, 0x50, 0x41,
+	0x52, 0x54, 0x59, 0x10, 0x05, 0x12, 0x12, 0x0a, 0x0e, 0x50, 0x4c, 0x41, 0x59, 0x45, 0x52, 0x5f,
+	0x52, 0x45, 0x4d, 0x4f, 0x56, 0x45, 0x44, 0x10, 0x06, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x41, 0x52,
+	0x54, 0x59, 0x5f, 0x49, 0x4e, 0x56, 0x49, 0x54, 0x45, 0x5f, 0x53, 0x45, 0x4e, 0x54, 0x10, 0x07,
+	0x12, 0x19, 0x0a, 0x15, 0x50, 0x41, 0x52, 0x54, 0x59, 0x5f, 0x49, 0x4e, 0x56, 0x49, 0x54, 0x45,
+	0x5f, 0x41, 0x43, 0x43, 0x45, 0x50, 0x54, 0x45, 0x44, 0x10, 0x08, 0x12, 0x19, 0x0a, 0x15, 0x50,
+	0x41, 0x52, 0x54, 0x59, 0x5f, 0x49, 0x4e, 0x56, 0x49, 0x54, 0x45, 0x5f, 0x52, 0x45, 0x4a, 0x45,
+	0x43, 0x54, 0x45, 0x44, 0x10, 0x09, 0x12, 0x0f, 0x0a, 0x0b, 0x50, 0x41, 0x52, 0x54, 0x59, 0x5f,
+	0x45, 0x4e, 0x44, 0x45, 0x44, 0x10, 0x0a, 0x12, 0x18, 0x0a, 0x14, 0x53, 0x45, 0x52, 0x56, 0x45,
+	0x52, 0x5f, 0x53, 0x48, 0x55, 0x54, 0x54, 0x49, 0x4e, 0x47, 0x5f, 0x44, 0x4f, 0x57, 0x4e, 0x10,
+	0x0b, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x41, 0x52, 0x54, 0x59, 0x5f, 0x4c, 0x45, 0x41, 0x44, 0x45,
+	0x52, 0x5f, 0x43, 0x48, 0x41, 0x4e, 0x47, 0x45, 0x44, 0x10, 0x0c, 0x12, 0x18, 0x0a, 0x14, 0x50,
+	0x41, 0x52, 0x54, 0x59, 0x5f, 0x49, 0x4e, 0x56, 0x49, 0x54, 0x45, 0x5f, 0x45, 0x58, 0x50, 0x49,
+	0x52, 0x45, 0x44, 0x10, 0x0d, 0x12, 0x1a, 0x0a, 0x16, 0x50, 0x41, 0x52, 0x54, 0x59, 0x5f, 0x49,
+	0x4e, 0x56, 0x49, 0x54, 0x45, 0x5f, 0x43, 0x41, 0x4e, 0x43, 0x45, 0x4c, 0x4c, 0x45, 0x44, 0x10,
+	0x0e, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x41, 0x52, 0x54, 0x59, 0x5f, 0x4a, 0x4f, 0x49, 0x4e, 0x5f,
+	0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x45, 0x44, 0x10, 0x0f, 0x12, 0x17, 0x0a, 0x13, 0x52,
+	0x45, 0x41, 0x44, 0x59, 0x5f, 0x43, 0x48, 0x45, 0x43, 0x4b, 0x5f, 0x53, 0x54, 0x41, 0x52, 0x54,
+	0x45, 0x44, 0x10, 0x10, 0x12, 0x19, 0x0a, 0x15, 0x52, 0x45, 0x41, 0x44, 0x59, 0x5f, 0x43, 0x48,
+	0x45, 0x43, 0x4b, 0x5f, 0x52, 0x45, 0x53, 0x50, 0x4f, 0x4e, 0x44, 0x45, 0x44, 0x10, 0x11, 0x12,
+	0x18, 0x0a, 0x14, 0x50, 0x41, 0x52, 0x54, 0x59, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f,
+	0x43, 0x48, 0x41, 0x4e, 0x47, 0x45, 0x44, 0x10, 0x12, 0x32, 0xd0, 0x03, 0x0a, 0x0b, 0x55, 0x73,
+	0x65, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x5f, 0x0a, 0x16, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x55, 0x73, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x12, 0x1f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x55, 0x73, 0x65,
+	0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x55, 0x73,
+	0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x5c, 0x0a, 0x18, 0x53, 0x74,
+	0x72, 0x65, 0x61, 0x6d, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x4a, 0x6f, 0x69, 0x6e, 0x65, 0x64,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
+	0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x6e, 0x50, 0x61, 0x72, 0x74, 0x79, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x6c,
+	0x61, 0x79, 0x65, 0x72, 0x73, 0x49, 0x6e, 0x50, 0x61, 0x72, 0x74, 0x79, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x5f, 0x0a, 0x16, 0x53, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x50, 0x61, 0x72, 0x74, 0x79, 0x49, 0x6e, 0x76, 0x69, 0x74, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x12, 0x1f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x61, 0x72, 0x74,
+	0x79, 0x49, 0x6e, 0x76, 0x69, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x61, 0x72,
+	0x74, 0x79, 0x49, 0x6e, 0x76, 0x69, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x46, 0x0a, 0x09, 0x50, 0x61, 0x72,
+	0x74, 0x79, 0x43, 0x68, 0x61, 0x74, 0x12, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e,
+	0x50, 0x61, 0x72, 0x74, 0x79, 0x43, 0x68, 0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x79, 0x43,
+	0x68, 0x61, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x28, 0x01, 0x30,
+	0x01, 0x12, 0x59, 0x0a, 0x14, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x44, 0x69, 0x72, 0x65, 0x63,
+	0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x12, 0x1d, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x73, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x73, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x42, 0x0b, 0x5a, 0x09,
+	0x2e, 0x2f, 0x67, 0x61, 0x6d, 0x70, 0x65, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
 }
 
 var (
@@ -263,23 +1083,52 @@ func file_game_proto_rawDescGZIP() []byte {
 	return file_game_proto_rawDescData
 }
 
-var file_game_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_game_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_game_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_game_proto_goTypes = []interface{}{
-	(*UserStatusChangeRequest)(nil),  // 0: protos.UserStatusChangeRequest
-	(*UserStatusChangeResponse)(nil), // 1: protos.UserStatusChangeResponse
-	(*PlayerInPartyRequest)(nil),     // 2: protos.PlayerInPartyRequest
-	(*PlayersInPartyResponse)(nil),   // 3: protos.PlayersInPartyResponse
+	(PresenceEventType)(0),           // 0: protos.PresenceEventType
+	(*PresenceEvent)(nil),            // 1: protos.PresenceEvent
+	(*UserStatusChangeRequest)(nil),  // 2: protos.UserStatusChangeRequest
+	(*UserStatusChangeResponse)(nil), // 3: protos.UserStatusChangeResponse
+	(*PlayerInPartyRequest)(nil),     // 4: protos.PlayerInPartyRequest
+	(*PlayersInPartyResponse)(nil),   // 5: protos.PlayersInPartyResponse
+	(*PartyInvitationsRequest)(nil),  // 6: protos.PartyInvitationsRequest
+	(*PartyInvitationsResponse)(nil), // 7: protos.PartyInvitationsResponse
+	(*PartyChatRequest)(nil),         // 8: protos.PartyChatRequest
+	(*PartyChatMessage)(nil),         // 9: protos.PartyChatMessage
+	(*PartyChatResponse)(nil),        // 10: protos.PartyChatResponse
+	(*DirectMessagesRequest)(nil),    // 11: protos.DirectMessagesRequest
+	(*DirectMessage)(nil),            // 12: protos.DirectMessage
+	(*DirectMessagesResponse)(nil),   // 13: protos.DirectMessagesResponse
+	(*timestamppb.Timestamp)(nil),    // 14: google.protobuf.Timestamp
 }
 var file_game_proto_depIdxs = []int32{
-	0, // 0: protos.UserService.StreamUserStatusChange:input_type -> protos.UserStatusChangeRequest
-	2, // 1: protos.UserService.StreamPlayerJoinedStatus:input_type -> protos.PlayerInPartyRequest
-	1, // 2: protos.UserService.StreamUserStatusChange:output_type -> protos.UserStatusChangeResponse
-	3, // 3: protos.UserService.StreamPlayerJoinedStatus:output_type -> protos.PlayersInPartyResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: protos.PresenceEvent.type:type_name -> protos.PresenceEventType
+	14, // 1: protos.PresenceEvent.timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 2: protos.UserStatusChangeResponse.event:type_name -> protos.PresenceEvent
+	1,  // 3: protos.PlayersInPartyResponse.event:type_name -> protos.PresenceEvent
+	1,  // 4: protos.PartyInvitationsResponse.event:type_name -> protos.PresenceEvent
+	14, // 5: protos.PartyChatMessage.sentAt:type_name -> google.protobuf.Timestamp
+	9,  // 6: protos.PartyChatResponse.message:type_name -> protos.PartyChatMessage
+	1,  // 7: protos.PartyChatResponse.event:type_name -> protos.PresenceEvent
+	14, // 8: protos.DirectMessage.sentAt:type_name -> google.protobuf.Timestamp
+	12, // 9: protos.DirectMessagesResponse.message:type_name -> protos.DirectMessage
+	1,  // 10: protos.DirectMessagesResponse.event:type_name -> protos.PresenceEvent
+	2,  // 11: protos.UserService.StreamUserStatusChange:input_type -> protos.UserStatusChangeRequest
+	4,  // 12: protos.UserService.StreamPlayerJoinedStatus:input_type -> protos.PlayerInPartyRequest
+	6,  // 13: protos.UserService.StreamPartyInvitations:input_type -> protos.PartyInvitationsRequest
+	8,  // 14: protos.UserService.PartyChat:input_type -> protos.PartyChatRequest
+	11, // 15: protos.UserService.StreamDirectMessages:input_type -> protos.DirectMessagesRequest
+	3,  // 16: protos.UserService.StreamUserStatusChange:output_type -> protos.UserStatusChangeResponse
+	5,  // 17: protos.UserService.StreamPlayerJoinedStatus:output_type -> protos.PlayersInPartyResponse
+	7,  // 18: protos.UserService.StreamPartyInvitations:output_type -> protos.PartyInvitationsResponse
+	10, // 19: protos.UserService.PartyChat:output_type -> protos.PartyChatResponse
+	13, // 20: protos.UserService.StreamDirectMessages:output_type -> protos.DirectMessagesResponse
+	16, // [16:21] is the sub-list for method output_type
+	11, // [11:16] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_game_proto_init() }
@@ -289,7 +1138,7 @@ func file_game_proto_init() {
 	}
 	if !protoimpl.UnsafeEnabled {
 		file_game_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UserStatusChangeRequest); i {
+			switch v := v.(*PresenceEvent); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -301,7 +1150,7 @@ func file_game_proto_init() {
 			}
 		}
 		file_game_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UserStatusChangeResponse); i {
+			switch v := v.(*UserStatusChangeRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -313,7 +1162,7 @@ func file_game_proto_init() {
 			}
 		}
 		file_game_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PlayerInPartyRequest); i {
+			switch v := v.(*UserStatusChangeResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -325,6 +1174,18 @@ func file_game_proto_init() {
 			}
 		}
 		file_game_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PlayerInPartyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_game_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*PlayersInPartyResponse); i {
 			case 0:
 				return &v.state
@@ -336,19 +1197,116 @@ func file_game_proto_init() {
 				return nil
 			}
 		}
+		file_game_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PartyInvitationsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_game_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PartyInvitationsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_game_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PartyChatRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_game_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PartyChatMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_game_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PartyChatResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_game_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DirectMessagesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_game_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DirectMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_game_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DirectMessagesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_game_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      1,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_game_proto_goTypes,
 		DependencyIndexes: file_game_proto_depIdxs,
+		EnumInfos:         file_game_proto_enumTypes,
 		MessageInfos:      file_game_proto_msgTypes,
 	}.Build()
 	File_game_proto = out.File
@@ -24,6 +24,9 @@ const _ = grpc.SupportPackageIsVersion7
 type UserServiceClient interface {
 	StreamUserStatusChange(ctx context.Context, in *UserStatusChangeRequest, opts ...grpc.CallOption) (UserService_StreamUserStatusChangeClient, error)
 	StreamPlayerJoinedStatus(ctx context.Context, in *PlayerInPartyRequest, opts ...grpc.CallOption) (UserService_StreamPlayerJoinedStatusClient, error)
+	StreamPartyInvitations(ctx context.Context, in *PartyInvitationsRequest, opts ...grpc.CallOption) (UserService_StreamPartyInvitationsClient, error)
+	PartyChat(ctx context.Context, opts ...grpc.CallOption) (UserService_PartyChatClient, error)
+	StreamDirectMessages(ctx context.Context, in *DirectMessagesRequest, opts ...grpc.CallOption) (UserService_StreamDirectMessagesClient, error)
 }
 
 type userServiceClient struct {
@@ -98,12 +101,110 @@ func (x *userServiceStreamPlayerJoinedStatusClient) Recv() (*PlayersInPartyRespo
 	return m, nil
 }
 
+func (c *userServiceClient) StreamPartyInvitations(ctx context.Context, in *PartyInvitationsRequest, opts ...grpc.CallOption) (UserService_StreamPartyInvitationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UserService_ServiceDesc.Streams[2], "/protos.UserService/StreamPartyInvitations", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userServiceStreamPartyInvitationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type UserService_StreamPartyInvitationsClient interface {
+	Recv() (*PartyInvitationsResponse, error)
+	grpc.ClientStream
+}
+
+type userServiceStreamPartyInvitationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *userServiceStreamPartyInvitationsClient) Recv() (*PartyInvitationsResponse, error) {
+	m := new(PartyInvitationsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userServiceClient) PartyChat(ctx context.Context, opts ...grpc.CallOption) (UserService_PartyChatClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UserService_ServiceDesc.Streams[3], "/protos.UserService/PartyChat", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userServicePartyChatClient{stream}
+	return x, nil
+}
+
+type UserService_PartyChatClient interface {
+	Send(*PartyChatRequest) error
+	Recv() (*PartyChatResponse, error)
+	grpc.ClientStream
+}
+
+type userServicePartyChatClient struct {
+	grpc.ClientStream
+}
+
+func (x *userServicePartyChatClient) Send(m *PartyChatRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *userServicePartyChatClient) Recv() (*PartyChatResponse, error) {
+	m := new(PartyChatResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *userServiceClient) StreamDirectMessages(ctx context.Context, in *DirectMessagesRequest, opts ...grpc.CallOption) (UserService_StreamDirectMessagesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &UserService_ServiceDesc.Streams[4], "/protos.UserService/StreamDirectMessages", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &userServiceStreamDirectMessagesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type UserService_StreamDirectMessagesClient interface {
+	Recv() (*DirectMessagesResponse, error)
+	grpc.ClientStream
+}
+
+type userServiceStreamDirectMessagesClient struct {
+	grpc.ClientStream
+}
+
+func (x *userServiceStreamDirectMessagesClient) Recv() (*DirectMessagesResponse, error) {
+	m := new(DirectMessagesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility
 type UserServiceServer interface {
 	StreamUserStatusChange(*UserStatusChangeRequest, UserService_StreamUserStatusChangeServer) error
 	StreamPlayerJoinedStatus(*PlayerInPartyRequest, UserService_StreamPlayerJoinedStatusServer) error
+	StreamPartyInvitations(*PartyInvitationsRequest, UserService_StreamPartyInvitationsServer) error
+	PartyChat(UserService_PartyChatServer) error
+	StreamDirectMessages(*DirectMessagesRequest, UserService_StreamDirectMessagesServer) error
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -117,6 +218,15 @@ func (UnimplementedUserServiceServer) StreamUserStatusChange(*UserStatusChangeRe
 func (UnimplementedUserServiceServer) StreamPlayerJoinedStatus(*PlayerInPartyRequest, UserService_StreamPlayerJoinedStatusServer) error {
 	return status.Errorf(codes.Unimplemented, "method StreamPlayerJoinedStatus not implemented")
 }
+func (UnimplementedUserServiceServer) StreamPartyInvitations(*PartyInvitationsRequest, UserService_StreamPartyInvitationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamPartyInvitations not implemented")
+}
+func (UnimplementedUserServiceServer) PartyChat(UserService_PartyChatServer) error {
+	return status.Errorf(codes.Unimplemented, "method PartyChat not implemented")
+}
+func (UnimplementedUserServiceServer) StreamDirectMessages(*DirectMessagesRequest, UserService_StreamDirectMessagesServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDirectMessages not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 
 // UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -172,6 +282,74 @@ func (x *userServiceStreamPlayerJoinedStatusServer) Send(m *PlayersInPartyRespon
 	return x.ServerStream.SendMsg(m)
 }
 
+func _UserService_StreamPartyInvitations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PartyInvitationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserServiceServer).StreamPartyInvitations(m, &userServiceStreamPartyInvitationsServer{stream})
+}
+
+type UserService_StreamPartyInvitationsServer interface {
+	Send(*PartyInvitationsResponse) error
+	grpc.ServerStream
+}
+
+type userServiceStreamPartyInvitationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *userServiceStreamPartyInvitationsServer) Send(m *PartyInvitationsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _UserService_PartyChat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(UserServiceServer).PartyChat(&userServicePartyChatServer{stream})
+}
+
+type UserService_PartyChatServer interface {
+	Send(*PartyChatResponse) error
+	Recv() (*PartyChatRequest, error)
+	grpc.ServerStream
+}
+
+type userServicePartyChatServer struct {
+	grpc.ServerStream
+}
+
+func (x *userServicePartyChatServer) Send(m *PartyChatResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *userServicePartyChatServer) Recv() (*PartyChatRequest, error) {
+	m := new(PartyChatRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _UserService_StreamDirectMessages_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DirectMessagesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(UserServiceServer).StreamDirectMessages(m, &userServiceStreamDirectMessagesServer{stream})
+}
+
+type UserService_StreamDirectMessagesServer interface {
+	Send(*DirectMessagesResponse) error
+	grpc.ServerStream
+}
+
+type userServiceStreamDirectMessagesServer struct {
+	grpc.ServerStream
+}
+
+func (x *userServiceStreamDirectMessagesServer) Send(m *DirectMessagesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -190,6 +368,22 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _UserService_StreamPlayerJoinedStatus_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamPartyInvitations",
+			Handler:       _UserService_StreamPartyInvitations_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "PartyChat",
+			Handler:       _UserService_PartyChat_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamDirectMessages",
+			Handler:       _UserService_StreamDirectMessages_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "game.proto",
 }
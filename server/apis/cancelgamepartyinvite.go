@@ -0,0 +1,173 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
+	"net/http"
+	"sync"
+)
+
+type CancelGamePartyInviteService interface {
+	ValidateRequest(ctx context.Context, requestData *models.CancelGamePartyInviteRequestData) []string
+	CancelInvitations(ctx context.Context, requestData *models.CancelGamePartyInviteRequestData) error
+}
+
+var cancelGamePartyInviteServiceStruct CancelGamePartyInviteService
+var cancelGamePartyInviteServiceOnce sync.Once
+
+type cancelGamePartyInviteService struct {
+	gameServer *models.GameServer
+	mongoDAO   mongodao.MongoDAO
+	eventBus   eventbus.EventBus
+}
+
+func InitCancelGamePartyInviteService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus) CancelGamePartyInviteService {
+	cancelGamePartyInviteServiceOnce.Do(func() {
+		cancelGamePartyInviteServiceStruct = &cancelGamePartyInviteService{
+			gameServer: gameSrvr,
+			mongoDAO:   mongodao,
+			eventBus:   evntBus,
+		}
+	})
+	return cancelGamePartyInviteServiceStruct
+}
+
+func GetCancelGamePartyInviteService() CancelGamePartyInviteService {
+	if cancelGamePartyInviteServiceStruct == nil {
+		panic("CancelGamePartyInvite Service not initialized")
+	}
+	return cancelGamePartyInviteServiceStruct
+}
+
+func (c cancelGamePartyInviteService) ValidateRequest(ctx context.Context, requestData *models.CancelGamePartyInviteRequestData) []string {
+	var errs []error
+	var errorString []string
+
+	if requestData.UserId == literals.EmptyString {
+		errs = append(errs, errors.New("empty userId in the request data"))
+	}
+
+	if requestData.PartyId == literals.EmptyString {
+		errs = append(errs, errors.New("empty partyId in the request data"))
+	}
+
+	// friend Ids should not be empty or repeated
+	if len(requestData.FriendIds) == 0 {
+		errs = append(errs, errors.New("no friendIds found in the request data"))
+	} else {
+		friendIdCount := make(map[string]int)
+		for _, friendId := range requestData.FriendIds {
+			if friendId == literals.EmptyString {
+				errs = append(errs, errors.New("found empty friendId in the request data"))
+				break
+			}
+			friendIdCount[friendId]++
+		}
+		for friendId, count := range friendIdCount {
+			if count > 1 {
+				errs = append(errs, errors.New("friendId "+friendId+" sent "+fmt.Sprint(count)+" times in the request data"))
+			}
+		}
+	}
+
+	// check party data only if the request data is correct
+	if errs == nil {
+		c.gameServer.Mutex.Lock()
+		if gameParty, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
+			errs = append(errs, errors.New("invalid partyId in the request data"))
+		} else if common.PartyLeader(gameParty) != requestData.UserId {
+			errs = append(errs, errors.New(requestData.UserId+" is not the leader of party "+requestData.PartyId))
+		} else {
+			for _, playerId := range requestData.FriendIds {
+				// invitations are outstanding until the player joins
+				if playerStatus := gameParty.Players[playerId]; playerStatus != models.PlayerInvitedStatus && playerStatus != models.PlayerAcceptedStatus {
+					errs = append(errs, errors.New("invitation of player "+playerId+" cannot be cancelled. Has status: "+string(playerStatus)))
+				}
+			}
+		}
+		c.gameServer.Mutex.Unlock()
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+		return errorString
+	}
+
+	return nil
+}
+
+func CancelGamePartyInviteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.CancelGamePartyInviteResponseData{
+			Success: success,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	requestData := &models.CancelGamePartyInviteRequestData{}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("failed to read cancel game party invite message: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	err = json.Unmarshal(data, requestData)
+	if err != nil {
+		fmt.Printf("failed to unmarshal cancel game party invite message : %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
+	fmt.Printf("Request data: %+v\n", requestData)
+
+	svc := GetCancelGamePartyInviteService()
+
+	errStrings = svc.ValidateRequest(ctx, requestData)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	err = svc.CancelInvitations(ctx, requestData)
+	if err != nil {
+		fmt.Printf("failed to cancel the game party invitations: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+// the slots held by the invitations are freed
+func (c cancelGamePartyInviteService) CancelInvitations(ctx context.Context, requestData *models.CancelGamePartyInviteRequestData) error {
+	return closeInvitations(ctx, c.gameServer, c.mongoDAO, c.eventBus, requestData.PartyId, requestData.UserId, requestData.FriendIds, models.PlayerCancelledStatus, models.PlayerInvitedStatus, models.PlayerAcceptedStatus)
+}
@@ -9,7 +9,11 @@ import (
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
 	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
+	"lite-social-presence-system/server/scheduler"
+	"math/rand/v2"
 	"net/http"
 	"sync"
 	"time"
@@ -18,22 +22,33 @@ import (
 )
 
 type CreateGamePartyService interface {
-	CreateAndStoreGameParty(ctx context.Context, requestData *models.CreateGamePartyRequestData) (string, error)
+	ValidateRequest(ctx context.Context, requestData *models.CreateGamePartyRequestData) []string
+	CreateAndStoreGameParty(ctx context.Context, requestData *models.CreateGamePartyRequestData) (string, string, error)
 }
 
 var createGamePartyServiceStruct CreateGamePartyService
 var createGamePartyServiceOnce sync.Once
 
 type createGamePartyService struct {
-	gameServer *models.GameServer
-	mongoDAO   mongodao.MongoDAO
+	gameServer      *models.GameServer
+	mongoDAO        mongodao.MongoDAO
+	eventBus        eventbus.EventBus
+	durationConfig  common.PartyDurationConfig
+	expiryScheduler scheduler.PartyExpiryScheduler
+	maxPartySize    int
+	autoLeaveParty  bool
 }
 
-func InitCreateGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO) CreateGamePartyService {
+func InitCreateGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, durationCfg common.PartyDurationConfig, expirySchdlr scheduler.PartyExpiryScheduler, maxPartySz int, autoLeave bool) CreateGamePartyService {
 	createGamePartyServiceOnce.Do(func() {
 		createGamePartyServiceStruct = &createGamePartyService{
-			gameServer: gameSrvr,
-			mongoDAO:   mongodao,
+			gameServer:      gameSrvr,
+			mongoDAO:        mongodao,
+			eventBus:        evntBus,
+			durationConfig:  durationCfg,
+			expiryScheduler: expirySchdlr,
+			maxPartySize:    maxPartySz,
+			autoLeaveParty:  autoLeave,
 		}
 	})
 	return createGamePartyServiceStruct
@@ -46,6 +61,64 @@ func GetCreateGamePartyService() CreateGamePartyService {
 	return createGamePartyServiceStruct
 }
 
+func (c createGamePartyService) ValidateRequest(ctx context.Context, requestData *models.CreateGamePartyRequestData) []string {
+	var errs []error
+	var errorString []string
+
+	if requestData.UserId == literals.EmptyString {
+		errs = append(errs, errors.New("no user ID passed"))
+	}
+
+	if _, err := requestedPartyDuration(requestData.Duration, c.durationConfig); err != nil {
+		errs = append(errs, err)
+	}
+
+	// leader and at least one more player
+	if requestData.Capacity != 0 && (requestData.Capacity < 2 || requestData.Capacity > c.maxPartySize) {
+		errs = append(errs, errors.New("capacity should be between 2 and "+fmt.Sprint(c.maxPartySize)))
+	}
+
+	switch requestData.Visibility {
+	case literals.EmptyString, models.GamePartyVisibilityInviteOnly, models.GamePartyVisibilityFriends, models.GamePartyVisibilityOpen:
+	default:
+		errs = append(errs, errors.New("invalid visibility "+string(requestData.Visibility)+" in the request data. should be one of "+
+			string(models.GamePartyVisibilityInviteOnly)+", "+string(models.GamePartyVisibilityFriends)+", "+string(models.GamePartyVisibilityOpen)))
+	}
+
+	if !c.autoLeaveParty {
+		c.gameServer.Mutex.Lock()
+		if partyId, ok := common.CurrentParty(c.gameServer, requestData.UserId); ok {
+			errs = append(errs, errors.New("user "+requestData.UserId+" is already in party "+partyId+". exit it before creating a new one"))
+		}
+		c.gameServer.Mutex.Unlock()
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+		return errorString
+	}
+
+	return nil
+}
+
+// duration asked for by the party leader, or the default duration if none was asked for
+func requestedPartyDuration(requestedDuration string, durationCfg common.PartyDurationConfig) (time.Duration, error) {
+	if requestedDuration == literals.EmptyString {
+		return durationCfg.Default, nil
+	}
+
+	duration, err := time.ParseDuration(requestedDuration)
+	if err != nil {
+		return 0, errors.New("invalid duration " + requestedDuration + " in the request data. e.g. 45m, 2h")
+	}
+	if duration < durationCfg.Min || duration > durationCfg.Max {
+		return 0, errors.New("duration should be between " + durationCfg.Min.String() + " and " + durationCfg.Max.String())
+	}
+	return duration, nil
+}
+
 func CreateGameParty(w http.ResponseWriter, r *http.Request) {
 	ctx := context.TODO()
 
@@ -54,12 +127,14 @@ func CreateGameParty(w http.ResponseWriter, r *http.Request) {
 	var errStrings []string
 	var err error
 	var partyId string
+	var joinCode string
 
 	defer func() {
 		result := models.CreateGamePartyResponseData{
-			Success: success,
-			PartyId: partyId,
-			Errors:  errStrings,
+			Success:  success,
+			PartyId:  partyId,
+			JoinCode: joinCode,
+			Errors:   errStrings,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(responseStatusCode)
@@ -84,20 +159,21 @@ func CreateGameParty(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if requestData.UserId == literals.EmptyString {
-		fmt.Println("no user ID passed")
-		err := errors.New("no user ID passed")
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
+	fmt.Printf("Request data: %+v\n", requestData)
+
+	svc := GetCreateGamePartyService()
 
+	errStrings = svc.ValidateRequest(ctx, requestData)
+	if errStrings != nil {
 		success = false
 		responseStatusCode = http.StatusBadRequest
-		errStrings = append(errStrings, err.Error())
 		return
 	}
 
-	fmt.Printf("Request data: %+v\n", requestData)
-
-	svc := GetCreateGamePartyService()
-	partyId, err = svc.CreateAndStoreGameParty(ctx, requestData)
+	partyId, joinCode, err = svc.CreateAndStoreGameParty(ctx, requestData)
 	if err != nil {
 		fmt.Printf("failed to create party: %v\n", err)
 		success = false
@@ -107,31 +183,91 @@ func CreateGameParty(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (c createGamePartyService) CreateAndStoreGameParty(ctx context.Context, requestData *models.CreateGamePartyRequestData) (string, error) {
+// returns the partyId and, for open parties, the join code
+func (c createGamePartyService) CreateAndStoreGameParty(ctx context.Context, requestData *models.CreateGamePartyRequestData) (string, string, error) {
+
+	duration, err := requestedPartyDuration(requestData.Duration, c.durationConfig)
+	if err != nil {
+		return literals.EmptyString, literals.EmptyString, err
+	}
 
 	partyId := uuid.NewString()
 	gameParty := &models.GameParty{
-		PartyId:   partyId,
-		CreatedBy: requestData.UserId,
-		StartTime: time.Now(),
-		Duration:  common.PartyDuration,
-		Status:    models.GamePartyStatusActive,
+		PartyId:    partyId,
+		CreatedBy:  requestData.UserId,
+		Leader:     requestData.UserId,
+		StartTime:  time.Now(),
+		Duration:   duration,
+		Status:     models.GamePartyStatusActive,
+		Capacity:   requestData.Capacity,
+		Visibility: requestData.Visibility,
+	}
+	if gameParty.Capacity == 0 {
+		gameParty.Capacity = c.maxPartySize
+	}
+	if gameParty.Visibility == literals.EmptyString {
+		gameParty.Visibility = models.GamePartyVisibilityInviteOnly
 	}
 
-	// store game party in DB
-	err := c.mongoDAO.CreateGameParty(ctx, gameParty)
-	if err != nil {
-		return literals.EmptyString, err
+	if c.autoLeaveParty {
+		err = LeaveCurrentParty(ctx, c.gameServer, c.mongoDAO, c.eventBus, requestData.UserId)
+		if err != nil {
+			return literals.EmptyString, literals.EmptyString, err
+		}
 	}
 
-	// update the user status to "in-game"
-	_, err = c.mongoDAO.UpdateUsersStatus(ctx, []string{requestData.UserId}, models.UserStatusInGame)
+	// hold the user for the new party so that a parallel create or join is rejected
+	c.gameServer.Mutex.Lock()
+	if currentPartyId, ok := common.CurrentParty(c.gameServer, requestData.UserId); ok {
+		c.gameServer.Mutex.Unlock()
+		return literals.EmptyString, literals.EmptyString, errors.New("user " + requestData.UserId + " is already in party " + currentPartyId)
+	}
+	c.gameServer.UserParties[requestData.UserId] = partyId
+	if gameParty.Visibility == models.GamePartyVisibilityOpen {
+		gameParty.JoinCode = newJoinCode(c.gameServer)
+		// reserved so that no other party gets the same code
+		c.gameServer.JoinCodes[gameParty.JoinCode] = partyId
+	}
+	c.gameServer.Mutex.Unlock()
+
+	// store game party in DB
+	err = c.mongoDAO.CreateGameParty(ctx, gameParty)
 	if err != nil {
-		return literals.EmptyString, err
+		c.gameServer.Mutex.Lock()
+		common.LeaveParty(c.gameServer, requestData.UserId, partyId)
+		delete(c.gameServer.JoinCodes, gameParty.JoinCode)
+		c.gameServer.Mutex.Unlock()
+		return literals.EmptyString, literals.EmptyString, err
 	}
 
 	c.gameServer.Mutex.Lock()
 	c.gameServer.Parties[partyId] = gameParty
 	c.gameServer.Mutex.Unlock()
-	return partyId, nil
+
+	// update the user status to "in-game"
+	err = UpdateUsersStatusFromMembership(ctx, c.gameServer, c.mongoDAO, c.eventBus, []string{requestData.UserId})
+	if err != nil {
+		return literals.EmptyString, literals.EmptyString, err
+	}
+
+	// party ends on its own once the duration is over
+	c.expiryScheduler.Schedule(partyId, gameParty.StartTime.Add(gameParty.Duration))
+	return partyId, gameParty.JoinCode, nil
+}
+
+// characters that cannot be mistaken for one another when the code is read out or typed
+const joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+const joinCodeLength = 6
+
+// join code that no active party is using. gameServer.Mutex has to be held
+func newJoinCode(gameServer *models.GameServer) string {
+	code := make([]byte, joinCodeLength)
+	for {
+		for i := range code {
+			code[i] = joinCodeAlphabet[rand.IntN(len(joinCodeAlphabet))]
+		}
+		if _, ok := gameServer.JoinCodes[string(code)]; !ok {
+			return string(code)
+		}
+	}
 }
@@ -0,0 +1,419 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/eventbus"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultDirectMessagesPageSize = 20
+	maxDirectMessagesPageSize     = 100
+)
+
+// 1:1 messages between friends. Every message is stored, a friend who is offline gets it on the next login
+type DirectMessagesService interface {
+	ValidateSendRequest(ctx context.Context, requestData *models.SendDirectMessageRequestData) []string
+	SendDirectMessage(ctx context.Context, requestData *models.SendDirectMessageRequestData) (*models.DirectMessage, error)
+	ValidateFriend(ctx context.Context, userId string, friendId string) []string
+	GetDirectMessages(ctx context.Context, userId string, friendId string, before time.Time, limit int) ([]*models.DirectMessage, error)
+	ValidateMarkReadRequest(ctx context.Context, requestData *models.MarkDirectMessagesReadRequestData) []string
+	MarkDirectMessagesRead(ctx context.Context, requestData *models.MarkDirectMessagesReadRequestData) error
+	GetUnreadCounts(ctx context.Context, userId string) (map[string]int, error)
+	GetUndeliveredMessages(ctx context.Context, userId string) ([]*models.DirectMessage, error)
+	MarkDelivered(ctx context.Context, directMessages ...*models.DirectMessage) error
+}
+
+var directMessagesServiceStruct DirectMessagesService
+var directMessagesServiceOnce sync.Once
+
+type directMessagesService struct {
+	mongoDAO       mongodao.MongoDAO
+	eventBus       eventbus.EventBus
+	maxMessageSize int
+}
+
+func InitDirectMessagesService(mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, maxMessageSz int) DirectMessagesService {
+	directMessagesServiceOnce.Do(func() {
+		directMessagesServiceStruct = &directMessagesService{
+			mongoDAO:       mongodao,
+			eventBus:       evntBus,
+			maxMessageSize: maxMessageSz,
+		}
+	})
+	return directMessagesServiceStruct
+}
+
+func GetDirectMessagesService() DirectMessagesService {
+	if directMessagesServiceStruct == nil {
+		panic("DirectMessages Service not initialized")
+	}
+	return directMessagesServiceStruct
+}
+
+func (c directMessagesService) ValidateSendRequest(ctx context.Context, requestData *models.SendDirectMessageRequestData) []string {
+	var errs []error
+	var errorString []string
+
+	if strings.TrimSpace(requestData.Text) == literals.EmptyString {
+		errs = append(errs, errors.New("empty text in the request data"))
+	} else if !utf8.ValidString(requestData.Text) {
+		errs = append(errs, errors.New("text in the request data is not valid UTF-8"))
+	} else if len(requestData.Text) > c.maxMessageSize {
+		errs = append(errs, errors.New("text is "+fmt.Sprint(len(requestData.Text))+" bytes. at most "+fmt.Sprint(c.maxMessageSize)+" bytes allowed"))
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+	}
+
+	return append(errorString, c.ValidateFriend(ctx, requestData.UserId, requestData.FriendId)...)
+}
+
+// messages can only be exchanged with accepted friends
+func (c directMessagesService) ValidateFriend(ctx context.Context, userId string, friendId string) []string {
+	var errs []error
+	var errorString []string
+
+	if userId == literals.EmptyString {
+		errs = append(errs, errors.New("empty userId in the request data"))
+	}
+
+	if friendId == literals.EmptyString {
+		errs = append(errs, errors.New("empty friendId in the request data"))
+	} else if friendId == userId {
+		errs = append(errs, errors.New("user "+userId+" cannot message themselves"))
+	}
+
+	if errs == nil {
+		if areFriends, err := c.mongoDAO.CheckFriendship(ctx, userId, []string{friendId}); err != nil || !areFriends {
+			errs = append(errs, errors.New("user "+friendId+" is not a friend of "+userId))
+		}
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+		return errorString
+	}
+
+	return nil
+}
+
+func (c directMessagesService) ValidateMarkReadRequest(ctx context.Context, requestData *models.MarkDirectMessagesReadRequestData) []string {
+	return c.ValidateFriend(ctx, requestData.UserId, requestData.FriendId)
+}
+
+// POST a direct message to a friend
+func SendDirectMessageHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	var directMessage *models.DirectMessage
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.SendDirectMessageResponseData{
+			Success: success,
+			Message: directMessage,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	requestData := &models.SendDirectMessageRequestData{}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("failed to read send direct message request: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	err = json.Unmarshal(data, requestData)
+	if err != nil {
+		fmt.Printf("failed to unmarshal send direct message request : %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// the sender is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
+	// the text itself is not logged
+	fmt.Printf("Request data: direct message from %v to %v\n", requestData.UserId, requestData.FriendId)
+
+	svc := GetDirectMessagesService()
+
+	errStrings = svc.ValidateSendRequest(ctx, requestData)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	directMessage, err = svc.SendDirectMessage(ctx, requestData)
+	if err != nil {
+		fmt.Printf("failed to send the direct message: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+// GET a page of the conversation with a friend, newest first
+func GetDirectMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	var directMessages []*models.DirectMessage
+	var nextBefore *time.Time
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.GetDirectMessagesResponseData{
+			Success:  success,
+			Messages: directMessages,
+			Before:   nextBefore,
+			Errors:   errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	friendId := mux.Vars(r)["friendId"]
+	userId, _ := auth.UserIdFromContext(r.Context())
+	fmt.Println("Request data: ", userId, friendId)
+
+	// optional. messages sent before this time, the latest messages otherwise
+	before := time.Now()
+	if value := r.URL.Query().Get("before"); value != literals.EmptyString {
+		before, err = time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			errStrings = append(errStrings, "invalid before "+value+" in the request. RFC 3339 time expected")
+		}
+	}
+
+	// optional. page size
+	limit := defaultDirectMessagesPageSize
+	if value := r.URL.Query().Get("limit"); value != literals.EmptyString {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxDirectMessagesPageSize {
+			errStrings = append(errStrings, "invalid limit "+value+" in the request. between 1 and "+fmt.Sprint(maxDirectMessagesPageSize)+" expected")
+		}
+	}
+
+	svc := GetDirectMessagesService()
+
+	errStrings = append(errStrings, svc.ValidateFriend(ctx, userId, friendId)...)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	directMessages, err = svc.GetDirectMessages(ctx, userId, friendId, before, limit)
+	if err != nil {
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// a full page may be followed by older messages
+	if len(directMessages) == limit {
+		nextBefore = &directMessages[len(directMessages)-1].SentAt
+	}
+	if directMessages == nil {
+		directMessages = []*models.DirectMessage{}
+	}
+}
+
+// GET the number of unread messages per friend
+func GetUnreadDirectMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	var unread map[string]int
+	var total int
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.GetUnreadDirectMessagesResponseData{
+			Success: success,
+			Unread:  unread,
+			Total:   total,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	// unread messages are always counted for the authenticated caller
+	userId, _ := auth.UserIdFromContext(r.Context())
+	fmt.Println("Request data: ", userId)
+
+	if userId == literals.EmptyString {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		errStrings = append(errStrings, "no user ID passed")
+		return
+	}
+
+	unread, err = GetDirectMessagesService().GetUnreadCounts(ctx, userId)
+	if err != nil {
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	for _, count := range unread {
+		total += count
+	}
+}
+
+// PATCH every message received from a friend as read
+func MarkDirectMessagesReadHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.MarkDirectMessagesReadResponseData{
+			Success: success,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	requestData := &models.MarkDirectMessagesReadRequestData{}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("failed to read mark direct messages read request: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	err = json.Unmarshal(data, requestData)
+	if err != nil {
+		fmt.Printf("failed to unmarshal mark direct messages read request : %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// the recipient is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
+	fmt.Printf("Request data: %+v\n", requestData)
+
+	svc := GetDirectMessagesService()
+
+	errStrings = svc.ValidateMarkReadRequest(ctx, requestData)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	err = svc.MarkDirectMessagesRead(ctx, requestData)
+	if err != nil {
+		fmt.Printf("failed to mark the direct messages read: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+// the message is stored first, then streamed to every device of the friend and of the sender
+func (c directMessagesService) SendDirectMessage(ctx context.Context, requestData *models.SendDirectMessageRequestData) (*models.DirectMessage, error) {
+
+	directMessage := &models.DirectMessage{
+		MessageId:   uuid.NewString(),
+		SenderId:    requestData.UserId,
+		RecipientId: requestData.FriendId,
+		Text:        requestData.Text,
+		SentAt:      time.Now(),
+	}
+
+	err := c.mongoDAO.InsertDirectMessage(ctx, directMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, userId := range []string{directMessage.RecipientId, directMessage.SenderId} {
+		c.eventBus.Publish(eventbus.MessageTopic(userId), &models.DirectMessageEvent{Message: directMessage})
+	}
+
+	return directMessage, nil
+}
+
+func (c directMessagesService) GetDirectMessages(ctx context.Context, userId string, friendId string, before time.Time, limit int) ([]*models.DirectMessage, error) {
+	return c.mongoDAO.FetchDirectMessages(ctx, userId, friendId, before, limit)
+}
+
+// messages received later are left unread
+func (c directMessagesService) MarkDirectMessagesRead(ctx context.Context, requestData *models.MarkDirectMessagesReadRequestData) error {
+	return c.mongoDAO.MarkDirectMessagesRead(ctx, requestData.UserId, requestData.FriendId, time.Now())
+}
+
+func (c directMessagesService) GetUnreadCounts(ctx context.Context, userId string) (map[string]int, error) {
+	return c.mongoDAO.CountUnreadDirectMessages(ctx, userId)
+}
+
+// messages sent to the user that no stream has delivered yet, oldest first
+func (c directMessagesService) GetUndeliveredMessages(ctx context.Context, userId string) ([]*models.DirectMessage, error) {
+	return c.mongoDAO.FetchUndeliveredDirectMessages(ctx, userId)
+}
+
+func (c directMessagesService) MarkDelivered(ctx context.Context, directMessages ...*models.DirectMessage) error {
+	var messageIds []string
+	for _, directMessage := range directMessages {
+		messageIds = append(messageIds, directMessage.MessageId)
+	}
+	if messageIds == nil {
+		return nil
+	}
+	return c.mongoDAO.MarkDirectMessagesDelivered(ctx, messageIds)
+}
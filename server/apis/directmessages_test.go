@@ -0,0 +1,148 @@
+package apis
+
+import (
+	"context"
+	"fmt"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/eventbus"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDirectMessageValidation(t *testing.T) {
+	maxMessageSize := 10
+
+	tests := []struct {
+		name     string
+		friendId string
+		text     string
+		wantSent bool
+	}{
+		{name: "message to a friend", friendId: "f1", text: "hi", wantSent: true},
+		{name: "message at the size limit", friendId: "f1", text: strings.Repeat("a", maxMessageSize), wantSent: true},
+		{name: "message over the size limit", friendId: "f1", text: strings.Repeat("a", maxMessageSize+1)},
+		{name: "blank message", friendId: "f1", text: "  "},
+		{name: "friend request not accepted", friendId: "pending", text: "hi"},
+		{name: "user who is not a friend", friendId: "u2", text: "hi"},
+		{name: "message to themselves", friendId: "u1", text: "hi"},
+	}
+
+	ctx := context.TODO()
+	mongoDAO := mongodao.NewInMemoryDao()
+	createTestUsers(t, mongoDAO, "u1", "u2", "f1", "pending")
+	befriend(t, mongoDAO, "u1", "f1")
+	if err := mongoDAO.StoreFriendRequests(ctx, "u1", []string{"pending"}); err != nil {
+		t.Fatalf("StoreFriendRequests: %v", err)
+	}
+	svc := directMessagesService{mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), maxMessageSize: maxMessageSize}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestData := &models.SendDirectMessageRequestData{UserId: "u1", FriendId: tt.friendId, Text: tt.text}
+			if errs := svc.ValidateSendRequest(ctx, requestData); (errs == nil) != tt.wantSent {
+				t.Errorf("ValidateSendRequest = %v, want sent %v", errs, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestDirectMessagesPagination(t *testing.T) {
+	ctx := context.TODO()
+	mongoDAO := mongodao.NewInMemoryDao()
+	createTestUsers(t, mongoDAO, "u1", "f1", "f2")
+	befriend(t, mongoDAO, "u1", "f1", "f2")
+	svc := directMessagesService{mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), maxMessageSize: 100}
+
+	// u1 and f1 take turns, f2 only adds noise to the conversation list
+	for i := 0; i < 5; i++ {
+		senderId, recipientId := "u1", "f1"
+		if i%2 == 1 {
+			senderId, recipientId = recipientId, senderId
+		}
+		for _, requestData := range []*models.SendDirectMessageRequestData{
+			{UserId: senderId, FriendId: recipientId, Text: fmt.Sprint(i)},
+			{UserId: "f2", FriendId: "u1", Text: "noise"},
+		} {
+			if _, err := svc.SendDirectMessage(ctx, requestData); err != nil {
+				t.Fatalf("SendDirectMessage: %v", err)
+			}
+		}
+		// distinct send times for the before cursor
+		time.Sleep(time.Millisecond)
+	}
+
+	var pages []string
+	before := time.Now()
+	for {
+		directMessages, err := svc.GetDirectMessages(ctx, "f1", "u1", before, 2)
+		if err != nil {
+			t.Fatalf("GetDirectMessages: %v", err)
+		}
+		if len(directMessages) == 0 {
+			break
+		}
+		var page []string
+		for _, directMessage := range directMessages {
+			page = append(page, directMessage.Text)
+		}
+		pages = append(pages, strings.Join(page, ","))
+		before = directMessages[len(directMessages)-1].SentAt
+	}
+
+	// newest first
+	if got, want := strings.Join(pages, "|"), "4,3|2,1|0"; got != want {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
+
+func TestDirectMessagesUnreadCounts(t *testing.T) {
+	ctx := context.TODO()
+	mongoDAO := mongodao.NewInMemoryDao()
+	createTestUsers(t, mongoDAO, "u1", "f1", "f2")
+	befriend(t, mongoDAO, "u1", "f1", "f2")
+	svc := directMessagesService{mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), maxMessageSize: 100}
+
+	send := func(senderId string, recipientId string, count int) {
+		for i := 0; i < count; i++ {
+			if _, err := svc.SendDirectMessage(ctx, &models.SendDirectMessageRequestData{UserId: senderId, FriendId: recipientId, Text: "hi"}); err != nil {
+				t.Fatalf("SendDirectMessage: %v", err)
+			}
+		}
+	}
+	unreadCounts := func(userId string) map[string]int {
+		unread, err := svc.GetUnreadCounts(ctx, userId)
+		if err != nil {
+			t.Fatalf("GetUnreadCounts: %v", err)
+		}
+		return unread
+	}
+
+	send("f1", "u1", 3)
+	send("f2", "u1", 2)
+	// messages sent by the user are never unread for them
+	send("u1", "f1", 1)
+
+	unread := unreadCounts("u1")
+	if unread["f1"] != 3 || unread["f2"] != 2 || len(unread) != 2 {
+		t.Fatalf("unread counts = %v, want f1: 3, f2: 2", unread)
+	}
+
+	markReadData := &models.MarkDirectMessagesReadRequestData{UserId: "u1", FriendId: "f1"}
+	if errs := svc.ValidateMarkReadRequest(ctx, markReadData); errs != nil {
+		t.Fatalf("ValidateMarkReadRequest: %v", errs)
+	}
+	if err := svc.MarkDirectMessagesRead(ctx, markReadData); err != nil {
+		t.Fatalf("MarkDirectMessagesRead: %v", err)
+	}
+	unread = unreadCounts("u1")
+	if unread["f1"] != 0 || unread["f2"] != 2 {
+		t.Errorf("unread counts after reading f1 = %v, want f2: 2", unread)
+	}
+
+	// the friend's own unread count is untouched by the user reading
+	if unread := unreadCounts("f1"); unread["u1"] != 1 {
+		t.Errorf("unread counts of f1 = %v, want u1: 1", unread)
+	}
+}
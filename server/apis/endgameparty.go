@@ -0,0 +1,238 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
+	"lite-social-presence-system/server/scheduler"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type EndGamePartyService interface {
+	ValidateRequest(ctx context.Context, requestData *models.EndGamePartyRequestData) []string
+	EndGameParty(ctx context.Context, requestData *models.EndGamePartyRequestData) error
+}
+
+var endGamePartyServiceStruct EndGamePartyService
+var endGamePartyServiceOnce sync.Once
+
+type endGamePartyService struct {
+	gameServer      *models.GameServer
+	mongoDAO        mongodao.MongoDAO
+	eventBus        eventbus.EventBus
+	expiryScheduler scheduler.PartyExpiryScheduler
+}
+
+func InitEndGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, expirySchdlr scheduler.PartyExpiryScheduler) EndGamePartyService {
+	endGamePartyServiceOnce.Do(func() {
+		endGamePartyServiceStruct = &endGamePartyService{
+			gameServer:      gameSrvr,
+			mongoDAO:        mongodao,
+			eventBus:        evntBus,
+			expiryScheduler: expirySchdlr,
+		}
+	})
+	return endGamePartyServiceStruct
+}
+
+func GetEndGamePartyService() EndGamePartyService {
+	if endGamePartyServiceStruct == nil {
+		panic("EndGameParty Service not initialized")
+	}
+	return endGamePartyServiceStruct
+}
+
+func (c endGamePartyService) ValidateRequest(ctx context.Context, requestData *models.EndGamePartyRequestData) []string {
+	var errs []error
+	var errorString []string
+
+	if requestData.UserId == literals.EmptyString {
+		errs = append(errs, errors.New("empty userId in the request data"))
+	}
+
+	if requestData.PartyId == literals.EmptyString {
+		errs = append(errs, errors.New("empty partyId in the request data"))
+	}
+
+	if errs == nil {
+		c.gameServer.Mutex.Lock()
+		if gameParty, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
+			errs = append(errs, errors.New("invalid partyId in the request data"))
+		} else if common.PartyLeader(gameParty) != requestData.UserId {
+			errs = append(errs, errors.New(requestData.UserId+" is not the leader of party "+requestData.PartyId))
+		}
+		c.gameServer.Mutex.Unlock()
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+		return errorString
+	}
+
+	return nil
+}
+
+func EndGamePartyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.EndGamePartyResponseData{
+			Success: success,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	requestData := &models.EndGamePartyRequestData{}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("failed to read end game party message: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	err = json.Unmarshal(data, requestData)
+	if err != nil {
+		fmt.Printf("failed to unmarshal end game party message : %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
+	fmt.Printf("Request data: %+v\n", requestData)
+
+	svc := GetEndGamePartyService()
+
+	errStrings = svc.ValidateRequest(ctx, requestData)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	err = svc.EndGameParty(ctx, requestData)
+	if err != nil {
+		fmt.Printf("failed to end the game party: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+func (c endGamePartyService) EndGameParty(ctx context.Context, requestData *models.EndGamePartyRequestData) error {
+	// party is ended here, nothing left to expire
+	c.expiryScheduler.Cancel(requestData.PartyId)
+
+	return TerminateGameParties(ctx, c.gameServer, c.mongoDAO, c.eventBus, []string{requestData.PartyId})
+}
+
+// end the game parties. Used both by the party leader and when the party duration is over.
+// Parties are marked over, the leader and every joined player become idle and the party streams are closed
+func TerminateGameParties(ctx context.Context, gameServer *models.GameServer, mongoDAO mongodao.MongoDAO, eventBus eventbus.EventBus, partyIds []string) error {
+
+	var usersStatusToBeUpdated []string
+	var partyIdsToBeTerminated []string
+	pendingInviteeIds := make(map[string][]string)
+	// lobby, ready-check or in-match
+	oldPartyStatus := make(map[string]models.GamePartyStatus)
+
+	gameServer.Mutex.Lock()
+	for _, partyId := range partyIds {
+		gameParty, ok := gameServer.Parties[partyId]
+		if !ok {
+			// already terminated
+			continue
+		}
+		usersStatusToBeUpdated = append(usersStatusToBeUpdated, common.PartyLeader(gameParty))
+		common.LeaveParty(gameServer, common.PartyLeader(gameParty), partyId)
+		for userId, status := range gameParty.Players {
+			if status == models.PlayerJoinedStatus {
+				usersStatusToBeUpdated = append(usersStatusToBeUpdated, userId)
+				common.LeaveParty(gameServer, userId, partyId)
+			} else if status == models.PlayerInvitedStatus {
+				pendingInviteeIds[partyId] = append(pendingInviteeIds[partyId], userId)
+			}
+		}
+		partyIdsToBeTerminated = append(partyIdsToBeTerminated, partyId)
+		oldPartyStatus[partyId] = gameParty.Status
+		delete(gameServer.Parties, partyId)
+		delete(gameServer.JoinCodes, gameParty.JoinCode)
+		delete(gameServer.ChatHistory, partyId)
+	}
+	gameServer.Mutex.Unlock()
+
+	if len(partyIdsToBeTerminated) == 0 {
+		return nil
+	}
+
+	// the parties are gone from memory already, so the subscribers are let go even if storing fails.
+	// The errors are returned once every step has run
+	var errs []error
+
+	/*
+		This approach is not efficient for larger dataset.
+		For larger data set, terminate every party separately.
+	*/
+	err := mongoDAO.UpdateGamePartyStatus(ctx, partyIdsToBeTerminated, models.GamePartyStatusOver)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// update users status to idle
+	err = UpdateUsersStatusFromMembership(ctx, gameServer, mongoDAO, eventBus, usersStatusToBeUpdated)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// let the party subscribers know and end the streams of the terminated parties
+	for _, partyId := range partyIdsToBeTerminated {
+		eventBus.Publish(eventbus.PartyTopic(partyId), &models.PresenceEvent{
+			Type:      models.PresenceEventPartyEnded,
+			PartyId:   partyId,
+			OldStatus: string(oldPartyStatus[partyId]),
+			NewStatus: string(models.GamePartyStatusOver),
+			Timestamp: time.Now(),
+		})
+		eventBus.CloseTopic(eventbus.PartyTopic(partyId))
+		eventBus.CloseTopic(eventbus.ChatTopic(partyId))
+
+		// invitations to the party are no longer pending
+		for _, inviteeId := range pendingInviteeIds[partyId] {
+			eventBus.Publish(eventbus.InvitationTopic(inviteeId), &models.PresenceEvent{
+				Type:         models.PresenceEventPartyEnded,
+				TargetUserId: inviteeId,
+				PartyId:      partyId,
+				OldStatus:    string(oldPartyStatus[partyId]),
+				NewStatus:    string(models.GamePartyStatusOver),
+				Timestamp:    time.Now(),
+			})
+		}
+	}
+
+	return errors.Join(errs...)
+}
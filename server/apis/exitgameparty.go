@@ -9,8 +9,12 @@ import (
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
 	"net/http"
 	"sync"
+	"time"
 )
 
 type ExitGamePartyService interface {
@@ -24,13 +28,15 @@ var exitGamePartyServiceOnce sync.Once
 type exitGamePartyService struct {
 	gameServer *models.GameServer
 	mongoDAO   mongodao.MongoDAO
+	eventBus   eventbus.EventBus
 }
 
-func InitExitGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO) ExitGamePartyService {
+func InitExitGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus) ExitGamePartyService {
 	exitGamePartyServiceOnce.Do(func() {
 		exitGamePartyServiceStruct = &exitGamePartyService{
 			gameServer: gameSrvr,
 			mongoDAO:   mongodao,
+			eventBus:   evntBus,
 		}
 	})
 	return exitGamePartyServiceStruct
@@ -56,9 +62,12 @@ func (c exitGamePartyService) ValidateRequest(ctx context.Context, requestData *
 	}
 
 	if errs == nil {
-		// user should be present in party as a player and should have status as joined
+		c.gameServer.Mutex.Lock()
+		// user should be the leader or present in party as a player and should have status as joined
 		if _, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
 			errs = append(errs, errors.New("invalid partyId in the request data"))
+		} else if common.PartyLeader(c.gameServer.Parties[requestData.PartyId]) == requestData.UserId {
+			// leader can always leave
 		} else if c.gameServer.Parties[requestData.PartyId].Players != nil {
 			if playerStatus, ok := c.gameServer.Parties[requestData.PartyId].Players[requestData.UserId]; ok {
 				// player can exit if his current status is "joined"
@@ -71,6 +80,7 @@ func (c exitGamePartyService) ValidateRequest(ctx context.Context, requestData *
 		} else {
 			errs = append(errs, errors.New("no players found in the game party"))
 		}
+		c.gameServer.Mutex.Unlock()
 	}
 
 	if len(errs) > 0 {
@@ -119,6 +129,9 @@ func ExitGamePartyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
 	fmt.Printf("Request data: %+v\n", requestData)
 
 	svc := GetExitGamePartyService()
@@ -142,20 +155,72 @@ func ExitGamePartyHandler(w http.ResponseWriter, r *http.Request) {
 
 func (c exitGamePartyService) ExitGameParty(ctx context.Context, requestData *models.ExitGamePartyRequestData) error {
 
+	c.gameServer.Mutex.Lock()
+	gameParty, ok := c.gameServer.Parties[requestData.PartyId]
+	if !ok {
+		// party ended since the request was validated
+		common.LeaveParty(c.gameServer, requestData.UserId, requestData.PartyId)
+		c.gameServer.Mutex.Unlock()
+		return errors.New("game party is over")
+	}
+	isLeader := common.PartyLeader(gameParty) == requestData.UserId
+	c.gameServer.Mutex.Unlock()
+	if isLeader {
+		// leadership moves on or the party ends
+		return GetGamePartyLeaderService().LeaderLeft(ctx, requestData.PartyId)
+	}
+
 	err := c.mongoDAO.UpdatePlayersDecisionForGameParty(ctx, requestData.PartyId, []string{requestData.UserId}, models.PlayerExitedStatus)
 	if err != nil {
 		return err
 	}
 
+	c.gameServer.Mutex.Lock()
+	if gameParty, ok := c.gameServer.Parties[requestData.PartyId]; ok {
+		gameParty.Players[requestData.UserId] = models.PlayerExitedStatus
+	}
+	common.LeaveParty(c.gameServer, requestData.UserId, requestData.PartyId)
+	c.gameServer.Mutex.Unlock()
+
 	// update user status to idle
-	_, err = c.mongoDAO.UpdateUsersStatus(ctx, []string{requestData.UserId}, models.UserStatusIdle)
+	err = UpdateUsersStatusFromMembership(ctx, c.gameServer, c.mongoDAO, c.eventBus, []string{requestData.UserId})
 	if err != nil {
 		return err
 	}
 
-	c.gameServer.Mutex.Lock()
-	c.gameServer.Parties[requestData.PartyId].Players[requestData.UserId] = models.PlayerExitedStatus
-	c.gameServer.Mutex.Unlock()
+	c.eventBus.Publish(eventbus.PartyTopic(requestData.PartyId), &models.PresenceEvent{
+		Type:        models.PresenceEventPlayerExitedParty,
+		ActorUserId: requestData.UserId,
+		PartyId:     requestData.PartyId,
+		OldStatus:   string(models.PlayerJoinedStatus),
+		NewStatus:   string(models.PlayerExitedStatus),
+		Timestamp:   time.Now(),
+	})
+
+	// the player is no longer part of the party, end its party streams
+	c.eventBus.UnsubscribeSubscriber(eventbus.PartyTopic(requestData.PartyId), requestData.UserId)
+	c.eventBus.UnsubscribeSubscriber(eventbus.ChatTopic(requestData.PartyId), requestData.UserId)
 
 	return nil
 }
+
+// leave the party the user is currently in, if any. Used to leave the previous party when auto_leave_party is set
+func LeaveCurrentParty(ctx context.Context, gameServer *models.GameServer, mongoDAO mongodao.MongoDAO, eventBus eventbus.EventBus, userId string) error {
+	gameServer.Mutex.Lock()
+	partyId, ok := common.CurrentParty(gameServer, userId)
+	if _, isActive := gameServer.Parties[partyId]; ok && !isActive {
+		// party is over, nothing to leave
+		common.LeaveParty(gameServer, userId, partyId)
+		ok = false
+	}
+	gameServer.Mutex.Unlock()
+	if !ok {
+		return nil
+	}
+
+	exitGamePartySvc := exitGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventBus}
+	return exitGamePartySvc.ExitGameParty(ctx, &models.ExitGamePartyRequestData{
+		PartyId: partyId,
+		UserId:  userId,
+	})
+}
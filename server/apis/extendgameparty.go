@@ -0,0 +1,203 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/scheduler"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type ExtendGamePartyService interface {
+	ValidateRequest(ctx context.Context, requestData *models.ExtendGamePartyRequestData) []string
+	ExtendGameParty(ctx context.Context, requestData *models.ExtendGamePartyRequestData) (*time.Time, error)
+}
+
+var extendGamePartyServiceStruct ExtendGamePartyService
+var extendGamePartyServiceOnce sync.Once
+
+type extendGamePartyService struct {
+	gameServer      *models.GameServer
+	mongoDAO        mongodao.MongoDAO
+	durationConfig  common.PartyDurationConfig
+	expiryScheduler scheduler.PartyExpiryScheduler
+}
+
+func InitExtendGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, durationCfg common.PartyDurationConfig, expirySchdlr scheduler.PartyExpiryScheduler) ExtendGamePartyService {
+	extendGamePartyServiceOnce.Do(func() {
+		extendGamePartyServiceStruct = &extendGamePartyService{
+			gameServer:      gameSrvr,
+			mongoDAO:        mongodao,
+			durationConfig:  durationCfg,
+			expiryScheduler: expirySchdlr,
+		}
+	})
+	return extendGamePartyServiceStruct
+}
+
+func GetExtendGamePartyService() ExtendGamePartyService {
+	if extendGamePartyServiceStruct == nil {
+		panic("ExtendGameParty Service not initialized")
+	}
+	return extendGamePartyServiceStruct
+}
+
+func (c extendGamePartyService) ValidateRequest(ctx context.Context, requestData *models.ExtendGamePartyRequestData) []string {
+	var errs []error
+	var errorString []string
+
+	if requestData.UserId == literals.EmptyString {
+		errs = append(errs, errors.New("empty userId in the request data"))
+	}
+
+	if requestData.PartyId == literals.EmptyString {
+		errs = append(errs, errors.New("empty partyId in the request data"))
+	}
+
+	extendBy, err := time.ParseDuration(requestData.ExtendBy)
+	if err != nil {
+		errs = append(errs, errors.New("invalid extendBy "+requestData.ExtendBy+" in the request data. e.g. 30m, 1h"))
+	} else if extendBy <= 0 {
+		errs = append(errs, errors.New("extendBy should be greater than 0"))
+	}
+
+	if errs == nil {
+		c.gameServer.Mutex.Lock()
+		gameParty, ok := c.gameServer.Parties[requestData.PartyId]
+		if !ok {
+			errs = append(errs, errors.New("invalid partyId in the request data"))
+		} else if common.PartyLeader(gameParty) != requestData.UserId {
+			errs = append(errs, errors.New(requestData.UserId+" is not the leader of party "+requestData.PartyId))
+		} else if gameParty.Duration+extendBy > c.durationConfig.Max {
+			errs = append(errs, errors.New("party cannot last more than "+c.durationConfig.Max.String()+". Current duration: "+gameParty.Duration.String()))
+		}
+		c.gameServer.Mutex.Unlock()
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+		return errorString
+	}
+
+	return nil
+}
+
+func ExtendGamePartyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+	var endTime *time.Time
+
+	defer func() {
+		result := models.ExtendGamePartyResponseData{
+			Success: success,
+			EndTime: endTime,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	requestData := &models.ExtendGamePartyRequestData{}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("failed to read extend game party message: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	err = json.Unmarshal(data, requestData)
+	if err != nil {
+		fmt.Printf("failed to unmarshal extend game party message : %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
+	fmt.Printf("Request data: %+v\n", requestData)
+
+	svc := GetExtendGamePartyService()
+
+	errStrings = svc.ValidateRequest(ctx, requestData)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	endTime, err = svc.ExtendGameParty(ctx, requestData)
+	if err != nil {
+		fmt.Printf("failed to extend the game party: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+func (c extendGamePartyService) ExtendGameParty(ctx context.Context, requestData *models.ExtendGamePartyRequestData) (*time.Time, error) {
+
+	// already validated
+	extendBy, _ := time.ParseDuration(requestData.ExtendBy)
+
+	// hold the extension so that parallel extends cannot together go past the maximum duration.
+	// The party could have ended after the request was validated
+	c.gameServer.Mutex.Lock()
+	gameParty, ok := c.gameServer.Parties[requestData.PartyId]
+	if !ok {
+		c.gameServer.Mutex.Unlock()
+		return nil, errors.New("game party is over")
+	}
+	if gameParty.Duration+extendBy > c.durationConfig.Max {
+		c.gameServer.Mutex.Unlock()
+		return nil, errors.New("party cannot last more than " + c.durationConfig.Max.String() + ". Current duration: " + gameParty.Duration.String())
+	}
+	gameParty.Duration += extendBy
+	duration := gameParty.Duration
+	c.gameServer.Mutex.Unlock()
+
+	err := c.mongoDAO.UpdateGamePartyDuration(ctx, requestData.PartyId, duration)
+	if err != nil {
+		c.gameServer.Mutex.Lock()
+		gameParty.Duration -= extendBy
+		c.gameServer.Mutex.Unlock()
+		return nil, err
+	}
+
+	c.gameServer.Mutex.Lock()
+	storedDuration := duration
+	duration = gameParty.Duration
+	endTime := gameParty.StartTime.Add(duration)
+	c.gameServer.Mutex.Unlock()
+
+	// a parallel extend held its extension after this one but stored it first
+	if duration != storedDuration {
+		err = c.mongoDAO.UpdateGamePartyDuration(ctx, requestData.PartyId, duration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.expiryScheduler.Schedule(requestData.PartyId, endTime)
+	return &endTime, nil
+}
@@ -0,0 +1,313 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
+	"lite-social-presence-system/server/scheduler"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+type GamePartyLeaderService interface {
+	ValidateRequest(ctx context.Context, requestData *models.TransferGamePartyLeaderRequestData) []string
+	TransferLeadership(ctx context.Context, requestData *models.TransferGamePartyLeaderRequestData) error
+	LeaderLeft(ctx context.Context, partyId string) error
+	LeaveLedParties(ctx context.Context, userId string) error
+}
+
+var gamePartyLeaderServiceStruct GamePartyLeaderService
+var gamePartyLeaderServiceOnce sync.Once
+
+type gamePartyLeaderService struct {
+	gameServer        *models.GameServer
+	mongoDAO          mongodao.MongoDAO
+	eventBus          eventbus.EventBus
+	expiryScheduler   scheduler.PartyExpiryScheduler
+	autoPromoteLeader bool
+}
+
+func InitGamePartyLeaderService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, expirySchdlr scheduler.PartyExpiryScheduler, autoPromote bool) GamePartyLeaderService {
+	gamePartyLeaderServiceOnce.Do(func() {
+		gamePartyLeaderServiceStruct = &gamePartyLeaderService{
+			gameServer:        gameSrvr,
+			mongoDAO:          mongodao,
+			eventBus:          evntBus,
+			expiryScheduler:   expirySchdlr,
+			autoPromoteLeader: autoPromote,
+		}
+	})
+	return gamePartyLeaderServiceStruct
+}
+
+func GetGamePartyLeaderService() GamePartyLeaderService {
+	if gamePartyLeaderServiceStruct == nil {
+		panic("GamePartyLeader Service not initialized")
+	}
+	return gamePartyLeaderServiceStruct
+}
+
+func (c gamePartyLeaderService) ValidateRequest(ctx context.Context, requestData *models.TransferGamePartyLeaderRequestData) []string {
+	var errs []error
+	var errorString []string
+
+	if requestData.UserId == literals.EmptyString {
+		errs = append(errs, errors.New("empty userId in the request data"))
+	}
+
+	if requestData.NewLeaderId == literals.EmptyString {
+		errs = append(errs, errors.New("empty newLeaderId in the request data"))
+	}
+
+	if requestData.PartyId == literals.EmptyString {
+		errs = append(errs, errors.New("empty partyId in the request data"))
+	}
+
+	if errs == nil {
+		c.gameServer.Mutex.Lock()
+		if gameParty, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
+			errs = append(errs, errors.New("invalid partyId in the request data"))
+		} else if common.PartyLeader(gameParty) != requestData.UserId {
+			errs = append(errs, errors.New(requestData.UserId+" is not the leader of party "+requestData.PartyId))
+		} else if playerStatus := gameParty.Players[requestData.NewLeaderId]; playerStatus != models.PlayerJoinedStatus {
+			// leadership can only go to someone who is playing
+			errs = append(errs, errors.New("player "+requestData.NewLeaderId+" has not joined the party. cannot be made the leader"))
+		}
+		c.gameServer.Mutex.Unlock()
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+		return errorString
+	}
+
+	return nil
+}
+
+func TransferGamePartyLeaderHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.TransferGamePartyLeaderResponseData{
+			Success: success,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	requestData := &models.TransferGamePartyLeaderRequestData{}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("failed to read transfer game party leader message: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	err = json.Unmarshal(data, requestData)
+	if err != nil {
+		fmt.Printf("failed to unmarshal transfer game party leader message : %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
+	fmt.Printf("Request data: %+v\n", requestData)
+
+	svc := GetGamePartyLeaderService()
+
+	errStrings = svc.ValidateRequest(ctx, requestData)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	err = svc.TransferLeadership(ctx, requestData)
+	if err != nil {
+		fmt.Printf("failed to transfer the game party leadership: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+// old leader stays in the party as a joined player
+func (c gamePartyLeaderService) TransferLeadership(ctx context.Context, requestData *models.TransferGamePartyLeaderRequestData) error {
+	return c.changeLeader(ctx, requestData.PartyId, requestData.UserId, models.PlayerJoinedStatus, requestData.NewLeaderId)
+}
+
+// leader exited or logged out. The player who joined first is promoted if auto promotion is on,
+// otherwise, or if nobody has joined, the party ends
+func (c gamePartyLeaderService) LeaderLeft(ctx context.Context, partyId string) error {
+
+	c.gameServer.Mutex.Lock()
+	gameParty, ok := c.gameServer.Parties[partyId]
+	if !ok {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("invalid partyId " + partyId)
+	}
+	leaderId := common.PartyLeader(gameParty)
+	newLeaderId := longestJoinedPlayer(gameParty)
+	c.gameServer.Mutex.Unlock()
+
+	if !c.autoPromoteLeader || newLeaderId == literals.EmptyString {
+		c.expiryScheduler.Cancel(partyId)
+		return TerminateGameParties(ctx, c.gameServer, c.mongoDAO, c.eventBus, []string{partyId})
+	}
+
+	err := c.changeLeader(ctx, partyId, leaderId, models.PlayerExitedStatus, newLeaderId)
+	if err != nil {
+		return err
+	}
+
+	// old leader is out of the party
+	c.gameServer.Mutex.Lock()
+	common.LeaveParty(c.gameServer, leaderId, partyId)
+	c.gameServer.Mutex.Unlock()
+	err = UpdateUsersStatusFromMembership(ctx, c.gameServer, c.mongoDAO, c.eventBus, []string{leaderId})
+	if err != nil {
+		return err
+	}
+
+	c.eventBus.Publish(eventbus.PartyTopic(partyId), &models.PresenceEvent{
+		Type:        models.PresenceEventPlayerExitedParty,
+		ActorUserId: leaderId,
+		PartyId:     partyId,
+		NewStatus:   string(models.PlayerExitedStatus),
+		Timestamp:   time.Now(),
+	})
+	c.eventBus.UnsubscribeSubscriber(eventbus.PartyTopic(partyId), leaderId)
+	c.eventBus.UnsubscribeSubscriber(eventbus.ChatTopic(partyId), leaderId)
+
+	return nil
+}
+
+// called when the user logs out
+func (c gamePartyLeaderService) LeaveLedParties(ctx context.Context, userId string) error {
+
+	var ledPartyIds []string
+	c.gameServer.Mutex.Lock()
+	for partyId, gameParty := range c.gameServer.Parties {
+		if common.PartyLeader(gameParty) == userId {
+			ledPartyIds = append(ledPartyIds, partyId)
+		}
+	}
+	c.gameServer.Mutex.Unlock()
+
+	for _, partyId := range ledPartyIds {
+		err := c.LeaderLeft(ctx, partyId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c gamePartyLeaderService) changeLeader(ctx context.Context, partyId string, oldLeaderId string, oldLeaderStatus models.GamePartyPlayerStatus, newLeaderId string) error {
+
+	changedAt := time.Now()
+
+	// a parallel transfer, or the new leader exiting, may have happened since the request was validated.
+	// Leadership moves in memory first and moves back if it cannot be stored
+	c.gameServer.Mutex.Lock()
+	gameParty, ok := c.gameServer.Parties[partyId]
+	if !ok {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("game party is over")
+	}
+	if common.PartyLeader(gameParty) != oldLeaderId {
+		c.gameServer.Mutex.Unlock()
+		return errors.New(oldLeaderId + " is not the leader of party " + partyId)
+	}
+	if playerStatus := gameParty.Players[newLeaderId]; playerStatus != models.PlayerJoinedStatus {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("player " + newLeaderId + " has not joined the party. cannot be made the leader")
+	}
+	if gameParty.JoinedAt == nil {
+		gameParty.JoinedAt = make(map[string]time.Time)
+	}
+	oldLeader := gameParty.Leader
+	newLeaderJoinedAt, newLeaderHasJoinedAt := gameParty.JoinedAt[newLeaderId]
+	gameParty.Leader = newLeaderId
+	gameParty.Players[oldLeaderId] = oldLeaderStatus
+	if oldLeaderStatus == models.PlayerJoinedStatus {
+		gameParty.JoinedAt[oldLeaderId] = changedAt
+	}
+	delete(gameParty.Players, newLeaderId)
+	delete(gameParty.JoinedAt, newLeaderId)
+	c.gameServer.Mutex.Unlock()
+
+	err := c.mongoDAO.UpdateGamePartyLeader(ctx, partyId, oldLeaderId, oldLeaderStatus, newLeaderId, changedAt)
+	if err != nil {
+		c.gameServer.Mutex.Lock()
+		if gameParty.Leader == newLeaderId {
+			gameParty.Leader = oldLeader
+			delete(gameParty.Players, oldLeaderId)
+			delete(gameParty.JoinedAt, oldLeaderId)
+			gameParty.Players[newLeaderId] = models.PlayerJoinedStatus
+			if newLeaderHasJoinedAt {
+				gameParty.JoinedAt[newLeaderId] = newLeaderJoinedAt
+			}
+		}
+		c.gameServer.Mutex.Unlock()
+		return err
+	}
+
+	c.eventBus.Publish(eventbus.PartyTopic(partyId), &models.PresenceEvent{
+		Type:         models.PresenceEventPartyLeaderChanged,
+		ActorUserId:  oldLeaderId,
+		TargetUserId: newLeaderId,
+		PartyId:      partyId,
+		Timestamp:    changedAt,
+	})
+
+	return nil
+}
+
+// joined player who has been in the party the longest. Empty if nobody has joined.
+// Players without a joined time (joined before it was recorded) come first, ties are broken by userId
+func longestJoinedPlayer(gameParty *models.GameParty) string {
+	var joinedPlayerIds []string
+	for playerId, playerStatus := range gameParty.Players {
+		if playerStatus == models.PlayerJoinedStatus {
+			joinedPlayerIds = append(joinedPlayerIds, playerId)
+		}
+	}
+	if len(joinedPlayerIds) == 0 {
+		return literals.EmptyString
+	}
+
+	sort.Slice(joinedPlayerIds, func(i, j int) bool {
+		joinedAtI, joinedAtJ := gameParty.JoinedAt[joinedPlayerIds[i]], gameParty.JoinedAt[joinedPlayerIds[j]]
+		if !joinedAtI.Equal(joinedAtJ) {
+			return joinedAtI.Before(joinedAtJ)
+		}
+		return joinedPlayerIds[i] < joinedPlayerIds[j]
+	})
+	return joinedPlayerIds[0]
+}
@@ -0,0 +1,64 @@
+package apis
+
+import (
+	"context"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentLeadershipTransfers(t *testing.T) {
+	ctx := context.TODO()
+	mongoDAO := mongodao.NewInMemoryDao()
+	createTestUsers(t, mongoDAO, "leader", "p1", "p2")
+	gameServer := newTestGameServer(t, mongoDAO)
+	storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Players: map[string]models.GamePartyPlayerStatus{
+		"p1": models.PlayerJoinedStatus,
+		"p2": models.PlayerJoinedStatus,
+	}})
+	svc := gamePartyLeaderService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus()}
+
+	// both transfers passed validation before either was stored
+	var wg sync.WaitGroup
+	errs := make(map[string]error)
+	var errsMutex sync.Mutex
+	for _, newLeaderId := range []string{"p1", "p2"} {
+		wg.Add(1)
+		go func(newLeaderId string) {
+			defer wg.Done()
+			err := svc.TransferLeadership(ctx, &models.TransferGamePartyLeaderRequestData{PartyId: "party1", UserId: "leader", NewLeaderId: newLeaderId})
+			errsMutex.Lock()
+			errs[newLeaderId] = err
+			errsMutex.Unlock()
+		}(newLeaderId)
+	}
+	wg.Wait()
+
+	if (errs["p1"] == nil) == (errs["p2"] == nil) {
+		t.Fatalf("transfers returned %v and %v, want exactly one to succeed", errs["p1"], errs["p2"])
+	}
+	wantLeaderId, otherPlayerId := "p1", "p2"
+	if errs["p1"] != nil {
+		wantLeaderId, otherPlayerId = "p2", "p1"
+	}
+
+	gameServer.Mutex.Lock()
+	leaderId := common.PartyLeader(gameServer.Parties["party1"])
+	gameServer.Mutex.Unlock()
+	storedParty, err := mongoDAO.GetGameParty(ctx, "party1")
+	if err != nil {
+		t.Fatalf("GetGameParty: %v", err)
+	}
+	if leaderId != wantLeaderId || common.PartyLeader(storedParty) != wantLeaderId {
+		t.Errorf("leader is %v in memory and %v in the database, want %v", leaderId, common.PartyLeader(storedParty), wantLeaderId)
+	}
+	if playerStatus := storedPlayerStatus(t, gameServer, mongoDAO, "party1", otherPlayerId); playerStatus != models.PlayerJoinedStatus {
+		t.Errorf("status of %v = %v, want %v", otherPlayerId, playerStatus, models.PlayerJoinedStatus)
+	}
+	if playerStatus := storedPlayerStatus(t, gameServer, mongoDAO, "party1", "leader"); playerStatus != models.PlayerJoinedStatus {
+		t.Errorf("status of the old leader = %v, want %v", playerStatus, models.PlayerJoinedStatus)
+	}
+}
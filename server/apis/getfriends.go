@@ -8,25 +8,32 @@ import (
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
 	"net/http"
+	"strconv"
 	"sync"
 )
 
 type GetFriendsService interface {
-	GetFriends(ctx context.Context, userId string) ([]*models.User, error)
+	GetFriends(ctx context.Context, userId string, includeParty bool) ([]*models.User, error)
 }
 
 var getFriendsServiceStruct GetFriendsService
 var getFriendsServiceOnce sync.Once
 
 type getFriendsService struct {
-	mongoDAO mongodao.MongoDAO
+	gameServer   *models.GameServer
+	mongoDAO     mongodao.MongoDAO
+	maxPartySize int
 }
 
-func InitGetFriendsService(mongodao mongodao.MongoDAO) GetFriendsService {
+func InitGetFriendsService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, maxPartySz int) GetFriendsService {
 	getFriendsServiceOnce.Do(func() {
 		getFriendsServiceStruct = &getFriendsService{
-			mongoDAO: mongodao,
+			gameServer:   gameSrvr,
+			mongoDAO:     mongodao,
+			maxPartySize: maxPartySz,
 		}
 	})
 	return getFriendsServiceStruct
@@ -61,9 +68,8 @@ func GetFriendsHandler(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(result)
 	}()
 
-	// Retrieve id from the query parameter
-	query := r.URL.Query()
-	userId := query.Get("id")
+	// friends are always fetched for the authenticated caller
+	userId, _ := auth.UserIdFromContext(r.Context())
 	fmt.Println("Request data: ", userId)
 
 	if userId == literals.EmptyString {
@@ -76,8 +82,20 @@ func GetFriendsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// optional. party of every in-game friend
+	includeParty := false
+	if value := r.URL.Query().Get("includeParty"); value != literals.EmptyString {
+		includeParty, err = strconv.ParseBool(value)
+		if err != nil {
+			success = false
+			responseStatusCode = http.StatusBadRequest
+			errStrings = append(errStrings, "invalid includeParty "+value+" in the request")
+			return
+		}
+	}
+
 	svc := GetFriendsServiceStruct()
-	friends, err = svc.GetFriends(ctx, userId)
+	friends, err = svc.GetFriends(ctx, userId, includeParty)
 	if err != nil {
 		success = false
 		responseStatusCode = http.StatusInternalServerError
@@ -90,7 +108,7 @@ func GetFriendsHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func (f getFriendsService) GetFriends(ctx context.Context, userId string) ([]*models.User, error) {
+func (f getFriendsService) GetFriends(ctx context.Context, userId string, includeParty bool) ([]*models.User, error) {
 
 	var friends []*models.User
 	var err error
@@ -100,5 +118,66 @@ func (f getFriendsService) GetFriends(ctx context.Context, userId string) ([]*mo
 		fmt.Println(err)
 		return nil, err
 	}
+	if includeParty {
+		f.addFriendParties(userId, friends)
+	}
 	return friends, nil
 }
+
+// set the party of every friend who is in one, and whether the user can join it or ask its leader for an invitation
+func (f getFriendsService) addFriendParties(userId string, friends []*models.User) {
+
+	friendIds := make(map[string]bool, len(friends))
+	for _, friend := range friends {
+		friendIds[friend.ID] = true
+	}
+
+	f.gameServer.Mutex.Lock()
+	defer f.gameServer.Mutex.Unlock()
+
+	for _, friend := range friends {
+		partyId, ok := common.CurrentParty(f.gameServer, friend.ID)
+		if !ok {
+			continue
+		}
+		gameParty, ok := f.gameServer.Parties[partyId]
+		if !ok {
+			continue
+		}
+
+		leaderId := common.PartyLeader(gameParty)
+		visibility := common.PartyVisibility(gameParty)
+		playerStatus := gameParty.Players[userId]
+		capacity := partyCapacity(gameParty, f.maxPartySize)
+		// joined players and pending invitations hold a slot
+		slotsLeft := capacity - 1 - countPlayers(gameParty, models.PlayerInvitedStatus, models.PlayerAcceptedStatus, models.PlayerJoinedStatus)
+		// the leader can only invite friends
+		leaderIsFriend := friendIds[leaderId]
+		isMember := leaderId == userId || playerStatus == models.PlayerJoinedStatus
+		_, requested := gameParty.JoinRequests[userId]
+
+		joinable := false
+		switch {
+		case isMember, gameParty.Status != models.GamePartyStatusActive:
+			// players can only join in the lobby
+		case playerStatus == models.PlayerAcceptedStatus:
+			joinable = capacity-1-countPlayers(gameParty, models.PlayerJoinedStatus) > 0
+		case visibility == models.GamePartyVisibilityFriends && leaderIsFriend && playerStatus != models.PlayerRemovedStatus:
+			// the user's own invitation is used up by joining
+			joinable = slotsLeft > 0 || (playerStatus == models.PlayerInvitedStatus && slotsLeft == 0)
+		}
+
+		canRequestJoin := !isMember && leaderIsFriend && !requested && slotsLeft > 0 &&
+			playerStatus != models.PlayerInvitedStatus && playerStatus != models.PlayerAcceptedStatus
+
+		friend.Party = &models.FriendParty{
+			PartyId:        partyId,
+			Leader:         leaderId,
+			Visibility:     visibility,
+			Capacity:       capacity,
+			SlotsLeft:      max(slotsLeft, 0),
+			Joinable:       joinable,
+			CanRequestJoin: canRequestJoin,
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type GetGamePartiesService interface {
+	GetGameParty(ctx context.Context, partyId string, callerId string) (*models.GamePartyDetails, error)
+	ListGameParties(ctx context.Context, userId string) []*models.GamePartyDetails
+}
+
+var getGamePartiesServiceStruct GetGamePartiesService
+var getGamePartiesServiceOnce sync.Once
+
+type getGamePartiesService struct {
+	gameServer   *models.GameServer
+	mongoDAO     mongodao.MongoDAO
+	maxPartySize int
+}
+
+func InitGetGamePartiesService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, maxPartySz int) GetGamePartiesService {
+	getGamePartiesServiceOnce.Do(func() {
+		getGamePartiesServiceStruct = &getGamePartiesService{
+			gameServer:   gameSrvr,
+			mongoDAO:     mongodao,
+			maxPartySize: maxPartySz,
+		}
+	})
+	return getGamePartiesServiceStruct
+}
+
+func GetGamePartiesServiceStruct() GetGamePartiesService {
+	if getGamePartiesServiceStruct == nil {
+		panic("GetGameParties Service not initialized")
+	}
+	return getGamePartiesServiceStruct
+}
+
+// GET Game Party
+func GetGamePartyHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := context.TODO()
+
+	var gameParty *models.GamePartyDetails
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.GetGamePartyResponseData{
+			Success: success,
+			Party:   gameParty,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	partyId := mux.Vars(r)["partyId"]
+	callerId, _ := auth.UserIdFromContext(r.Context())
+	fmt.Println("Request data: ", partyId)
+
+	svc := GetGamePartiesServiceStruct()
+	gameParty, err = svc.GetGameParty(ctx, partyId, callerId)
+	if err != nil {
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	if gameParty == nil {
+		success = false
+		responseStatusCode = http.StatusNotFound
+		errStrings = append(errStrings, "game party "+partyId+" not found")
+		return
+	}
+}
+
+// GET Game Parties of a user
+func ListGamePartiesHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := context.TODO()
+
+	var gameParties []*models.GamePartyDetails
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+
+	defer func() {
+		result := models.ListGamePartiesResponseData{
+			Success: success,
+			Parties: gameParties,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	// parties are listed for the authenticated caller. userId is optional and has to be the caller
+	callerId, _ := auth.UserIdFromContext(r.Context())
+	userId := r.URL.Query().Get("userId")
+	fmt.Println("Request data: ", userId)
+
+	if userId == literals.EmptyString {
+		userId = callerId
+	} else if userId != callerId {
+		err := errors.New("user " + callerId + " cannot list the game parties of " + userId)
+
+		success = false
+		responseStatusCode = http.StatusForbidden
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	svc := GetGamePartiesServiceStruct()
+	gameParties = svc.ListGameParties(ctx, userId)
+	if gameParties == nil {
+		gameParties = []*models.GamePartyDetails{}
+	}
+	fmt.Printf("Found %d game parties\n", len(gameParties))
+}
+
+// active parties are served from the game server, ended ones from the DB
+func (c getGamePartiesService) GetGameParty(ctx context.Context, partyId string, callerId string) (*models.GamePartyDetails, error) {
+
+	c.gameServer.Mutex.Lock()
+	if gameParty, ok := c.gameServer.Parties[partyId]; ok {
+		details := c.gamePartyDetails(gameParty, time.Now(), callerId)
+		c.gameServer.Mutex.Unlock()
+		return details, nil
+	}
+	c.gameServer.Mutex.Unlock()
+
+	gameParty, err := c.mongoDAO.GetGameParty(ctx, partyId)
+	if err != nil || gameParty == nil {
+		return nil, err
+	}
+	return c.gamePartyDetails(gameParty, time.Now(), callerId), nil
+}
+
+// active parties the user leads, is invited to, has accepted the invite of or has joined. Oldest first
+func (c getGamePartiesService) ListGameParties(ctx context.Context, userId string) []*models.GamePartyDetails {
+
+	now := time.Now()
+	var gameParties []*models.GamePartyDetails
+
+	c.gameServer.Mutex.Lock()
+	for _, gameParty := range c.gameServer.Parties {
+		isMember := common.PartyLeader(gameParty) == userId
+		switch gameParty.Players[userId] {
+		case models.PlayerInvitedStatus, models.PlayerAcceptedStatus, models.PlayerJoinedStatus:
+			isMember = true
+		}
+		if isMember {
+			gameParties = append(gameParties, c.gamePartyDetails(gameParty, now, userId))
+		}
+	}
+	c.gameServer.Mutex.Unlock()
+
+	sort.Slice(gameParties, func(i, j int) bool {
+		return gameParties[i].StartTime.Before(gameParties[j].StartTime)
+	})
+	return gameParties
+}
+
+// copy of the party that is safe to use once gameServer.Mutex is released.
+// The join code is only shown to the leader and the joined players, the join requests only to the leader
+func (c getGamePartiesService) gamePartyDetails(gameParty *models.GameParty, now time.Time, callerId string) *models.GamePartyDetails {
+
+	endTime := gameParty.StartTime.Add(gameParty.Duration)
+	remainingTime := endTime.Sub(now).Round(time.Second)
+	if remainingTime < 0 || gameParty.Status == models.GamePartyStatusOver {
+		remainingTime = 0
+	}
+
+	players := make(map[string]models.GamePartyPlayerStatus, len(gameParty.Players))
+	for playerId, playerStatus := range gameParty.Players {
+		players[playerId] = playerStatus
+	}
+
+	joinCode := literals.EmptyString
+	if common.PartyLeader(gameParty) == callerId || gameParty.Players[callerId] == models.PlayerJoinedStatus {
+		joinCode = gameParty.JoinCode
+	}
+
+	var readyCheck *models.ReadyCheck
+	if gameParty.ReadyCheck != nil {
+		readyCheck = &models.ReadyCheck{
+			StartedBy: gameParty.ReadyCheck.StartedBy,
+			StartedAt: gameParty.ReadyCheck.StartedAt,
+			ExpiresAt: gameParty.ReadyCheck.ExpiresAt,
+			Responses: make(map[string]models.ReadyCheckResponse, len(gameParty.ReadyCheck.Responses)),
+		}
+		for playerId, response := range gameParty.ReadyCheck.Responses {
+			readyCheck.Responses[playerId] = response
+		}
+	}
+
+	var joinRequests map[string]time.Time
+	if common.PartyLeader(gameParty) == callerId && len(gameParty.JoinRequests) > 0 {
+		joinRequests = make(map[string]time.Time, len(gameParty.JoinRequests))
+		for userId, requestedAt := range gameParty.JoinRequests {
+			joinRequests[userId] = requestedAt
+		}
+	}
+
+	return &models.GamePartyDetails{
+		PartyId:       gameParty.PartyId,
+		CreatedBy:     gameParty.CreatedBy,
+		Leader:        common.PartyLeader(gameParty),
+		StartTime:     gameParty.StartTime,
+		EndTime:       endTime,
+		RemainingTime: remainingTime.String(),
+		Status:        gameParty.Status,
+		Capacity:      partyCapacity(gameParty, c.maxPartySize),
+		Players:       players,
+		Visibility:    common.PartyVisibility(gameParty),
+		JoinCode:      joinCode,
+		JoinRequests:  joinRequests,
+		ReadyCheck:    readyCheck,
+	}
+}
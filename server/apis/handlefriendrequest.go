@@ -9,6 +9,7 @@ import (
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
 	"net/http"
 	"sync"
 )
@@ -129,6 +130,9 @@ func HandleFriendRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
 	fmt.Printf("Request data: %+v\n", requestData)
 
 	svc := GetHandleFriendRequestService()
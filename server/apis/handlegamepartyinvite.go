@@ -9,8 +9,11 @@ import (
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/eventbus"
 	"net/http"
 	"sync"
+	"time"
 )
 
 type HandleGamePartyInviteService interface {
@@ -24,13 +27,17 @@ var handleGamePartyInviteServiceOnce sync.Once
 type handleGamePartyInviteService struct {
 	gameServer *models.GameServer
 	mongoDAO   mongodao.MongoDAO
+	eventBus   eventbus.EventBus
+	inviteTTL  time.Duration
 }
 
-func InitHandleGamePartyInviteService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO) HandleGamePartyInviteService {
+func InitHandleGamePartyInviteService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, invitationTTL time.Duration) HandleGamePartyInviteService {
 	handleGamePartyInviteServiceOnce.Do(func() {
 		handleGamePartyInviteServiceStruct = &handleGamePartyInviteService{
 			gameServer: gameSrvr,
 			mongoDAO:   mongodao,
+			eventBus:   evntBus,
+			inviteTTL:  invitationTTL,
 		}
 	})
 	return handleGamePartyInviteServiceStruct
@@ -60,14 +67,17 @@ func (c handleGamePartyInviteService) ValidateRequest(ctx context.Context, reque
 	}
 
 	if errs == nil {
+		c.gameServer.Mutex.Lock()
 		// user should be present in party as a player and should have status as invited
 		if _, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
 			errs = append(errs, errors.New("invalid partyId in the request data"))
 		} else if c.gameServer.Parties[requestData.PartyId].Players != nil {
 			if playerStatus, ok := c.gameServer.Parties[requestData.PartyId].Players[requestData.UserId]; ok {
-				// player can accept or reject if his current status is "invited"
+				// player can accept or reject if his current status is "invited" and the invitation has not expired
 				if playerStatus != models.PlayerInvitedStatus {
 					errs = append(errs, errors.New("player "+requestData.UserId+" has current status: "+string(playerStatus)+". cannot update decision to "+string(requestData.Status)))
+				} else if !time.Now().Before(invitationExpiresAt(c.gameServer.Parties[requestData.PartyId], requestData.UserId, c.inviteTTL)) {
+					errs = append(errs, errors.New("invitation of player "+requestData.UserId+" to party "+requestData.PartyId+" has "+string(models.PlayerExpiredStatus)))
 				}
 			} else {
 				errs = append(errs, errors.New("player not found in the game party"))
@@ -75,6 +85,7 @@ func (c handleGamePartyInviteService) ValidateRequest(ctx context.Context, reque
 		} else {
 			errs = append(errs, errors.New("no players found in the game party"))
 		}
+		c.gameServer.Mutex.Unlock()
 	}
 
 	if len(errs) > 0 {
@@ -123,6 +134,9 @@ func HandleGamePartyInviteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
 	fmt.Printf("Request data: %+v\n", requestData)
 
 	svc := GetHandleGamePartyInviteService()
@@ -146,14 +160,50 @@ func HandleGamePartyInviteHandler(w http.ResponseWriter, r *http.Request) {
 
 func (c handleGamePartyInviteService) HandleInvitationToGameParty(ctx context.Context, requestData *models.HandleGamePartyInviteRequestData) error {
 
+	// the party may have ended, or the invitation expired or been cancelled, since the request was validated
+	c.gameServer.Mutex.Lock()
+	gameParty, ok := c.gameServer.Parties[requestData.PartyId]
+	if !ok {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("game party is over")
+	}
+	if playerStatus := gameParty.Players[requestData.UserId]; playerStatus != models.PlayerInvitedStatus {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("player " + requestData.UserId + " has current status: " + string(playerStatus) + ". cannot update decision to " + string(requestData.Status))
+	}
+	if !time.Now().Before(invitationExpiresAt(gameParty, requestData.UserId, c.inviteTTL)) {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("invitation of player " + requestData.UserId + " to party " + requestData.PartyId + " has " + string(models.PlayerExpiredStatus))
+	}
+	gameParty.Players[requestData.UserId] = requestData.Status
+	c.gameServer.Mutex.Unlock()
+
 	err := c.mongoDAO.UpdatePlayersDecisionForGameParty(ctx, requestData.PartyId, []string{requestData.UserId}, requestData.Status)
 	if err != nil {
+		// the invitation is pending again, unless it has been closed in the meantime
+		c.gameServer.Mutex.Lock()
+		if gameParty.Players[requestData.UserId] == requestData.Status {
+			gameParty.Players[requestData.UserId] = models.PlayerInvitedStatus
+		}
+		c.gameServer.Mutex.Unlock()
 		return err
 	}
 
-	c.gameServer.Mutex.Lock()
-	c.gameServer.Parties[requestData.PartyId].Players[requestData.UserId] = requestData.Status
-	c.gameServer.Mutex.Unlock()
+	eventType := models.PresenceEventPartyInviteAccepted
+	if requestData.Status == models.PlayerRejectedStatus {
+		eventType = models.PresenceEventPartyInviteRejected
+	}
+	event := &models.PresenceEvent{
+		Type:        eventType,
+		ActorUserId: requestData.UserId,
+		PartyId:     requestData.PartyId,
+		OldStatus:   string(models.PlayerInvitedStatus),
+		NewStatus:   string(requestData.Status),
+		Timestamp:   time.Now(),
+	}
+	c.eventBus.Publish(eventbus.PartyTopic(requestData.PartyId), event)
+	// invitation is no longer pending on the other devices of the user
+	c.eventBus.Publish(eventbus.InvitationTopic(requestData.UserId), event)
 
 	return nil
 }
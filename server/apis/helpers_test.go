@@ -0,0 +1,132 @@
+package apis
+
+import (
+	"context"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/common"
+	"testing"
+	"time"
+)
+
+// users registered and logged in, i.e. idle
+func createTestUsers(t *testing.T, mongoDAO mongodao.MongoDAO, userIds ...string) {
+	t.Helper()
+
+	ctx := context.TODO()
+	for _, userId := range userIds {
+		if err := mongoDAO.CreateUser(ctx, &models.User{ID: userId}, "pwd"); err != nil {
+			t.Fatalf("CreateUser %v: %v", userId, err)
+		}
+	}
+	if _, err := mongoDAO.UpdateUsersStatus(ctx, userIds, models.UserStatusIdle); err != nil {
+		t.Fatalf("UpdateUsersStatus: %v", err)
+	}
+}
+
+// accepted friendship between the user and every friend
+func befriend(t *testing.T, mongoDAO mongodao.MongoDAO, userId string, friendIds ...string) {
+	t.Helper()
+
+	ctx := context.TODO()
+	if err := mongoDAO.StoreFriendRequests(ctx, userId, friendIds); err != nil {
+		t.Fatalf("StoreFriendRequests: %v", err)
+	}
+	if err := mongoDAO.UpdateFriendRequestsStatus(ctx, userId, friendIds, models.FriendshipStatusAccepted); err != nil {
+		t.Fatalf("UpdateFriendRequestsStatus: %v", err)
+	}
+}
+
+// game server loaded from the database, the same way the server starts
+func newTestGameServer(t *testing.T, mongoDAO mongodao.MongoDAO) *models.GameServer {
+	t.Helper()
+
+	gameServer, err := common.NewGameServer(mongoDAO)
+	if err != nil {
+		t.Fatalf("NewGameServer: %v", err)
+	}
+	return gameServer
+}
+
+// stores the party, in the lobby unless another status is set, and loads it in the game server.
+// The players were invited by the leader just now
+func storeTestParty(t *testing.T, gameServer *models.GameServer, mongoDAO mongodao.MongoDAO, gameParty *models.GameParty) *models.GameParty {
+	t.Helper()
+
+	ctx := context.TODO()
+	now := time.Now()
+	gameParty.StartTime = now
+	gameParty.Duration = time.Hour
+	if gameParty.Status == "" {
+		gameParty.Status = models.GamePartyStatusActive
+	}
+	gameParty.Leader = gameParty.CreatedBy
+	if err := mongoDAO.CreateGameParty(ctx, gameParty); err != nil {
+		t.Fatalf("CreateGameParty: %v", err)
+	}
+
+	gameParty.InvitedBy = make(map[string]string)
+	gameParty.InvitedAt = make(map[string]time.Time)
+	gameParty.JoinedAt = make(map[string]time.Time)
+	for playerId, playerStatus := range gameParty.Players {
+		if err := mongoDAO.AddPlayerToGameParty(ctx, gameParty.PartyId, playerId, playerStatus); err != nil {
+			t.Fatalf("AddPlayerToGameParty: %v", err)
+		}
+		gameParty.InvitedBy[playerId] = gameParty.CreatedBy
+		gameParty.InvitedAt[playerId] = now
+		if playerStatus == models.PlayerJoinedStatus {
+			gameParty.JoinedAt[playerId] = now
+		}
+	}
+
+	gameServer.Mutex.Lock()
+	gameServer.Parties[gameParty.PartyId] = gameParty
+	gameServer.UserParties[gameParty.CreatedBy] = gameParty.PartyId
+	for playerId, playerStatus := range gameParty.Players {
+		if playerStatus == models.PlayerJoinedStatus {
+			gameServer.UserParties[playerId] = gameParty.PartyId
+		}
+	}
+	if gameParty.JoinCode != "" {
+		gameServer.JoinCodes[gameParty.JoinCode] = gameParty.PartyId
+	}
+	gameServer.Mutex.Unlock()
+
+	return gameParty
+}
+
+// status of the player in the game server and in the database. They have to match
+func storedPlayerStatus(t *testing.T, gameServer *models.GameServer, mongoDAO mongodao.MongoDAO, partyId string, userId string) models.GamePartyPlayerStatus {
+	t.Helper()
+
+	storedParty, err := mongoDAO.GetGameParty(context.TODO(), partyId)
+	if err != nil || storedParty == nil {
+		t.Fatalf("GetGameParty = %v, %v", storedParty, err)
+	}
+
+	gameServer.Mutex.Lock()
+	defer gameServer.Mutex.Unlock()
+	playerStatus := gameServer.Parties[partyId].Players[userId]
+	if storedParty.Players[userId] != playerStatus {
+		t.Fatalf("player %v has status %v in memory and %v in the database", userId, playerStatus, storedParty.Players[userId])
+	}
+	return playerStatus
+}
+
+// status of the party in the game server and in the database. They have to match
+func storedPartyStatus(t *testing.T, gameServer *models.GameServer, mongoDAO mongodao.MongoDAO, partyId string) models.GamePartyStatus {
+	t.Helper()
+
+	storedParty, err := mongoDAO.GetGameParty(context.TODO(), partyId)
+	if err != nil || storedParty == nil {
+		t.Fatalf("GetGameParty = %v, %v", storedParty, err)
+	}
+
+	gameServer.Mutex.Lock()
+	defer gameServer.Mutex.Unlock()
+	partyStatus := gameServer.Parties[partyId].Status
+	if storedParty.Status != partyStatus {
+		t.Fatalf("party %v has status %v in memory and %v in the database", partyId, partyStatus, storedParty.Status)
+	}
+	return partyStatus
+}
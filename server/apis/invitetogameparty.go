@@ -9,8 +9,12 @@ import (
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
 	"net/http"
 	"sync"
+	"time"
 )
 
 type InviteToGamePartyService interface {
@@ -22,15 +26,19 @@ var inviteToGamePartyServiceStruct InviteToGamePartyService
 var inviteToGamePartyServiceOnce sync.Once
 
 type inviteToGamePartyService struct {
-	gameServer *models.GameServer
-	mongoDAO   mongodao.MongoDAO
+	gameServer   *models.GameServer
+	mongoDAO     mongodao.MongoDAO
+	eventBus     eventbus.EventBus
+	maxPartySize int
 }
 
-func InitInviteToGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO) InviteToGamePartyService {
+func InitInviteToGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, maxPartySz int) InviteToGamePartyService {
 	inviteToGamePartyServiceOnce.Do(func() {
 		inviteToGamePartyServiceStruct = &inviteToGamePartyService{
-			gameServer: gameSrvr,
-			mongoDAO:   mongodao,
+			gameServer:   gameSrvr,
+			mongoDAO:     mongodao,
+			eventBus:     evntBus,
+			maxPartySize: maxPartySz,
 		}
 	})
 	return inviteToGamePartyServiceStruct
@@ -47,10 +55,12 @@ func (c inviteToGamePartyService) ValidateRequest(ctx context.Context, requestDa
 	var errs []error
 	var errorString []string
 
+	// the parties are also updated by the background workers
+	c.gameServer.Mutex.Lock()
 	if requestData.UserId == literals.EmptyString {
 		errs = append(errs, errors.New("empty userId in the request data"))
-	} else if c.gameServer.Parties[requestData.PartyId] != nil && requestData.UserId != c.gameServer.Parties[requestData.PartyId].CreatedBy {
-		errs = append(errs, errors.New("party "+requestData.PartyId+" not created by "+requestData.UserId))
+	} else if c.gameServer.Parties[requestData.PartyId] != nil && requestData.UserId != common.PartyLeader(c.gameServer.Parties[requestData.PartyId]) {
+		errs = append(errs, errors.New(requestData.UserId+" is not the leader of party "+requestData.PartyId))
 	}
 
 	// friend Ids should not be empty
@@ -85,23 +95,33 @@ func (c inviteToGamePartyService) ValidateRequest(ctx context.Context, requestDa
 		} else {
 			if _, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
 				errs = append(errs, errors.New("invalid partyId in the request data"))
+			} else if partyStatus := c.gameServer.Parties[requestData.PartyId].Status; partyStatus != models.GamePartyStatusActive {
+				errs = append(errs, errors.New("party "+requestData.PartyId+" has status: "+string(partyStatus)+". players can only be invited in the lobby"))
 			} else if c.gameServer.Parties[requestData.PartyId].Players != nil {
 				// players present
 				for _, playerId := range requestData.FriendIds {
 					if playerStatus, ok := c.gameServer.Parties[requestData.PartyId].Players[playerId]; ok {
 						// playerId already present
-						// can be invited again if player status is 'rejected/exited/removed'
-						if playerStatus != models.PlayerExitedStatus && playerStatus != models.PlayerRejectedStatus && playerStatus != models.PlayerRemovedStatus {
-							// cannot invite this player
+						if !canBeInvited(playerStatus) {
 							errs = append(errs, errors.New("player "+playerId+" cannot be invited. Has status: "+string(playerStatus)))
 						}
 					}
 				}
 
 			}
+
+			// pending invitations hold a slot until they are rejected
+			if gameParty, ok := c.gameServer.Parties[requestData.PartyId]; ok && errs == nil {
+				capacity, slotsLeft := inviteSlotsLeft(gameParty, c.maxPartySize)
+				if len(requestData.FriendIds) > slotsLeft {
+					errs = append(errs, errors.New("party "+requestData.PartyId+" has a capacity of "+fmt.Sprint(capacity)+" players and "+fmt.Sprint(slotsLeft)+" slots left. cannot invite "+fmt.Sprint(len(requestData.FriendIds))+" players"))
+				}
+			}
 		}
 	}
 
+	c.gameServer.Mutex.Unlock()
+
 	if len(errs) > 0 {
 		for _, err := range errs {
 			errorString = append(errorString, err.Error())
@@ -148,6 +168,9 @@ func InviteToGamePartyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
 	fmt.Printf("Request data: %+v\n", requestData)
 
 	svc := GetInviteToGamePartyService()
@@ -177,22 +200,110 @@ func (c inviteToGamePartyService) StoreInvitationToGameParty(ctx context.Context
 	}
 	if areFriends {
 
-		err = c.mongoDAO.AddInviteesToGameParty(ctx, requestData.PartyId, requestData.FriendIds)
+		// capacity is checked again while holding the lock so that parallel invitations cannot overfill the party.
+		// The invitees are added in memory first and rolled back if they cannot be stored
+		c.gameServer.Mutex.Lock()
+		gameParty, ok := c.gameServer.Parties[requestData.PartyId]
+		if !ok {
+			c.gameServer.Mutex.Unlock()
+			return errors.New("game party is over")
+		}
+		if gameParty.Status != models.GamePartyStatusActive {
+			c.gameServer.Mutex.Unlock()
+			return errors.New("party " + requestData.PartyId + " has status: " + string(gameParty.Status) + ". players can only be invited in the lobby")
+		}
+		for _, playerId := range requestData.FriendIds {
+			if playerStatus, ok := gameParty.Players[playerId]; ok && !canBeInvited(playerStatus) {
+				c.gameServer.Mutex.Unlock()
+				return errors.New("player " + playerId + " cannot be invited. Has status: " + string(playerStatus))
+			}
+		}
+		capacity, slotsLeft := inviteSlotsLeft(gameParty, c.maxPartySize)
+		if len(requestData.FriendIds) > slotsLeft {
+			c.gameServer.Mutex.Unlock()
+			return errors.New("party " + requestData.PartyId + " has a capacity of " + fmt.Sprint(capacity) + " players and " + fmt.Sprint(slotsLeft) + " slots left. cannot invite " + fmt.Sprint(len(requestData.FriendIds)) + " players")
+		}
+		// if no players have been added till now, initialize the maps
+		if gameParty.Players == nil {
+			gameParty.Players = make(map[string]models.GamePartyPlayerStatus)
+		}
+		if gameParty.InvitedBy == nil {
+			gameParty.InvitedBy = make(map[string]string)
+		}
+		if gameParty.InvitedAt == nil {
+			gameParty.InvitedAt = make(map[string]time.Time)
+		}
+		invitedAt := time.Now()
+		// players invited for the first time have no old status
+		oldPlayersStatus := make(map[string]models.GamePartyPlayerStatus)
+		oldInvitedBy := make(map[string]string)
+		oldInvitedAt := make(map[string]time.Time)
+		for _, playerId := range requestData.FriendIds {
+			if playerStatus, ok := gameParty.Players[playerId]; ok {
+				oldPlayersStatus[playerId] = playerStatus
+				oldInvitedBy[playerId] = gameParty.InvitedBy[playerId]
+				oldInvitedAt[playerId] = gameParty.InvitedAt[playerId]
+			}
+			gameParty.Players[playerId] = models.PlayerInvitedStatus
+			gameParty.InvitedBy[playerId] = requestData.UserId
+			gameParty.InvitedAt[playerId] = invitedAt
+		}
+		c.gameServer.Mutex.Unlock()
+
+		err = c.mongoDAO.AddInviteesToGameParty(ctx, requestData.PartyId, requestData.UserId, requestData.FriendIds, invitedAt)
 		if err != nil {
+			c.gameServer.Mutex.Lock()
+			for _, playerId := range requestData.FriendIds {
+				// invitations answered or closed in the meantime are left alone
+				if gameParty.Players[playerId] != models.PlayerInvitedStatus || !gameParty.InvitedAt[playerId].Equal(invitedAt) {
+					continue
+				}
+				if oldPlayerStatus, ok := oldPlayersStatus[playerId]; ok {
+					gameParty.Players[playerId] = oldPlayerStatus
+					gameParty.InvitedBy[playerId] = oldInvitedBy[playerId]
+					gameParty.InvitedAt[playerId] = oldInvitedAt[playerId]
+				} else {
+					delete(gameParty.Players, playerId)
+					delete(gameParty.InvitedBy, playerId)
+					delete(gameParty.InvitedAt, playerId)
+				}
+			}
+			c.gameServer.Mutex.Unlock()
 			return err
 		}
 
+		// invited users do not have to ask to join anymore
 		c.gameServer.Mutex.Lock()
-		// if no players have been added till now, initialize the map
-		if c.gameServer.Parties[requestData.PartyId].Players == nil {
-			c.gameServer.Parties[requestData.PartyId].Players = make(map[string]models.GamePartyPlayerStatus)
-		}
 		for _, playerId := range requestData.FriendIds {
-			c.gameServer.Parties[requestData.PartyId].Players[playerId] = models.PlayerInvitedStatus
+			delete(gameParty.JoinRequests, playerId)
 		}
 		c.gameServer.Mutex.Unlock()
 
+		for _, playerId := range requestData.FriendIds {
+			event := &models.PresenceEvent{
+				Type:         models.PresenceEventPartyInviteSent,
+				ActorUserId:  requestData.UserId,
+				TargetUserId: playerId,
+				PartyId:      requestData.PartyId,
+				OldStatus:    string(oldPlayersStatus[playerId]),
+				NewStatus:    string(models.PlayerInvitedStatus),
+				Timestamp:    invitedAt,
+			}
+			c.eventBus.Publish(eventbus.PartyTopic(requestData.PartyId), event)
+			// invitee's inbox
+			c.eventBus.Publish(eventbus.InvitationTopic(playerId), event)
+		}
+
 	}
 
 	return nil
 }
+
+// players already in the party can be invited again if their status is 'rejected/exited/removed/expired/cancelled'
+func canBeInvited(playerStatus models.GamePartyPlayerStatus) bool {
+	switch playerStatus {
+	case models.PlayerExitedStatus, models.PlayerRejectedStatus, models.PlayerRemovedStatus, models.PlayerExpiredStatus, models.PlayerCancelledStatus:
+		return true
+	}
+	return false
+}
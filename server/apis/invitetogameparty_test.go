@@ -0,0 +1,86 @@
+package apis
+
+import (
+	"context"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/eventbus"
+	"sync"
+	"testing"
+)
+
+func TestInviteToGamePartyCapacity(t *testing.T) {
+	tests := []struct {
+		name        string
+		capacity    int
+		players     map[string]models.GamePartyPlayerStatus
+		friendIds   []string
+		wantInvited bool
+	}{
+		{name: "invitations fill the party", capacity: 3, players: map[string]models.GamePartyPlayerStatus{"p1": models.PlayerJoinedStatus}, friendIds: []string{"u1"}, wantInvited: true},
+		{name: "more invitations than slots left", capacity: 3, players: map[string]models.GamePartyPlayerStatus{"p1": models.PlayerJoinedStatus}, friendIds: []string{"u1", "u2"}},
+		{name: "pending invitations hold their slot", capacity: 3, players: map[string]models.GamePartyPlayerStatus{"p1": models.PlayerInvitedStatus}, friendIds: []string{"u1", "u2"}},
+		{name: "rejected invitations free their slot", capacity: 3, players: map[string]models.GamePartyPlayerStatus{"p1": models.PlayerRejectedStatus}, friendIds: []string{"u1", "u2"}, wantInvited: true},
+		{name: "capacity defaults to max_party_size", players: map[string]models.GamePartyPlayerStatus{"p1": models.PlayerJoinedStatus}, friendIds: []string{"u1", "u2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mongoDAO := mongodao.NewInMemoryDao()
+			createTestUsers(t, mongoDAO, "leader", "u1", "u2", "p1")
+			befriend(t, mongoDAO, "leader", "u1", "u2", "p1")
+			gameServer := newTestGameServer(t, mongoDAO)
+			storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Capacity: tt.capacity, Players: tt.players})
+
+			svc := inviteToGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), maxPartySize: 3}
+			requestData := &models.InviteToGamePartyRequestData{PartyId: "party1", UserId: "leader", FriendIds: tt.friendIds}
+			errs := svc.ValidateRequest(ctx, requestData)
+			if (errs == nil) != tt.wantInvited {
+				t.Fatalf("ValidateRequest = %v, want invited %v", errs, tt.wantInvited)
+			}
+			if errs != nil {
+				return
+			}
+			if err := svc.StoreInvitationToGameParty(ctx, requestData); err != nil {
+				t.Fatalf("StoreInvitationToGameParty: %v", err)
+			}
+			for _, friendId := range tt.friendIds {
+				if playerStatus := storedPlayerStatus(t, gameServer, mongoDAO, "party1", friendId); playerStatus != models.PlayerInvitedStatus {
+					t.Errorf("status of %v = %v, want %v", friendId, playerStatus, models.PlayerInvitedStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestInviteToGamePartyConcurrentInvitesKeepCapacity(t *testing.T) {
+	ctx := context.TODO()
+	mongoDAO := mongodao.NewInMemoryDao()
+	createTestUsers(t, mongoDAO, "leader", "u1", "u2", "u3")
+	befriend(t, mongoDAO, "leader", "u1", "u2", "u3")
+	gameServer := newTestGameServer(t, mongoDAO)
+	storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Capacity: 2})
+	svc := inviteToGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), maxPartySize: 8}
+
+	// every invitation passed validation before any of them was stored
+	var wg sync.WaitGroup
+	for _, friendId := range []string{"u1", "u2", "u3"} {
+		wg.Add(1)
+		go func(friendId string) {
+			defer wg.Done()
+			svc.StoreInvitationToGameParty(ctx, &models.InviteToGamePartyRequestData{PartyId: "party1", UserId: "leader", FriendIds: []string{friendId}})
+		}(friendId)
+	}
+	wg.Wait()
+
+	invited := 0
+	for _, friendId := range []string{"u1", "u2", "u3"} {
+		if storedPlayerStatus(t, gameServer, mongoDAO, "party1", friendId) == models.PlayerInvitedStatus {
+			invited++
+		}
+	}
+	if invited != 1 {
+		t.Errorf("%v players invited to a party with room for 1", invited)
+	}
+}
@@ -9,8 +9,12 @@ import (
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
 	"net/http"
 	"sync"
+	"time"
 )
 
 type JoinGamePartyService interface {
@@ -22,15 +26,21 @@ var joinGamePartyServiceStruct JoinGamePartyService
 var joinGamePartyServiceOnce sync.Once
 
 type joinGamePartyService struct {
-	gameServer *models.GameServer
-	mongoDAO   mongodao.MongoDAO
+	gameServer     *models.GameServer
+	mongoDAO       mongodao.MongoDAO
+	eventBus       eventbus.EventBus
+	maxPartySize   int
+	autoLeaveParty bool
 }
 
-func InitJoinGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO) JoinGamePartyService {
+func InitJoinGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, maxPartySz int, autoLeave bool) JoinGamePartyService {
 	joinGamePartyServiceOnce.Do(func() {
 		joinGamePartyServiceStruct = &joinGamePartyService{
-			gameServer: gameSrvr,
-			mongoDAO:   mongodao,
+			gameServer:     gameSrvr,
+			mongoDAO:       mongodao,
+			eventBus:       evntBus,
+			maxPartySize:   maxPartySz,
+			autoLeaveParty: autoLeave,
 		}
 	})
 	return joinGamePartyServiceStruct
@@ -51,25 +61,69 @@ func (c joinGamePartyService) ValidateRequest(ctx context.Context, requestData *
 		errs = append(errs, errors.New("empty userId in the request data"))
 	}
 
-	if requestData.PartyId == literals.EmptyString {
-		errs = append(errs, errors.New("empty partyId in the request data"))
+	if requestData.PartyId == literals.EmptyString && requestData.JoinCode == literals.EmptyString {
+		errs = append(errs, errors.New("empty partyId and joinCode in the request data"))
 	}
 
+	var leaderId string
+	friendsOnly := false
 	if errs == nil {
-		// user should be present in party as a player and should have status as accepted
-		if _, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
-			errs = append(errs, errors.New("invalid partyId in the request data"))
-		} else if c.gameServer.Parties[requestData.PartyId].Players != nil {
-			if playerStatus, ok := c.gameServer.Parties[requestData.PartyId].Players[requestData.UserId]; ok {
-				// player can join if his current status is "accepted"
-				if playerStatus != models.PlayerAcceptedStatus {
-					errs = append(errs, errors.New("player "+requestData.UserId+" has current status: "+string(playerStatus)+". cannot update decision to "+string(models.PlayerJoinedStatus)))
-				}
-			} else {
-				errs = append(errs, errors.New("player not found in the game party"))
+		c.gameServer.Mutex.Lock()
+
+		// party of the join code, if only the code was sent
+		if requestData.PartyId == literals.EmptyString {
+			if partyId, ok := c.gameServer.JoinCodes[requestData.JoinCode]; ok {
+				requestData.PartyId = partyId
 			}
+		}
+
+		if gameParty, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
+			errs = append(errs, errors.New("invalid partyId or joinCode in the request data"))
 		} else {
-			errs = append(errs, errors.New("no players found in the game party"))
+			leaderId = common.PartyLeader(gameParty)
+			playerStatus, isPlayer := gameParty.Players[requestData.UserId]
+			visibility := common.PartyVisibility(gameParty)
+
+			switch {
+			case gameParty.Status != models.GamePartyStatusActive:
+				errs = append(errs, errors.New("party "+requestData.PartyId+" has status: "+string(gameParty.Status)+". players can only join in the lobby"))
+			case leaderId == requestData.UserId:
+				errs = append(errs, errors.New("user "+requestData.UserId+" is the leader of the party"))
+			case playerStatus == models.PlayerAcceptedStatus:
+				// player who accepted the invitation can always join
+			case playerStatus == models.PlayerJoinedStatus || playerStatus == models.PlayerRemovedStatus:
+				errs = append(errs, errors.New("player "+requestData.UserId+" has current status: "+string(playerStatus)+". cannot update decision to "+string(models.PlayerJoinedStatus)))
+			case visibility == models.GamePartyVisibilityFriends:
+				// friendship with the leader is checked below, outside the lock
+				friendsOnly = true
+			case visibility == models.GamePartyVisibilityOpen && requestData.JoinCode != literals.EmptyString:
+				if requestData.JoinCode != gameParty.JoinCode {
+					errs = append(errs, errors.New("invalid joinCode for party "+requestData.PartyId))
+				}
+			case isPlayer:
+				errs = append(errs, errors.New("player "+requestData.UserId+" has current status: "+string(playerStatus)+". cannot update decision to "+string(models.PlayerJoinedStatus)))
+			default:
+				errs = append(errs, errors.New("player not found in the game party. party "+requestData.PartyId+" is "+string(visibility)))
+			}
+
+			if errs == nil {
+				capacity, slotsLeft := joinSlotsLeft(gameParty, requestData.UserId, c.maxPartySize)
+				if slotsLeft <= 0 {
+					errs = append(errs, errors.New("party "+requestData.PartyId+" is full. capacity: "+fmt.Sprint(capacity)+" players, 0 slots left"))
+				}
+			}
+		}
+
+		if partyId, ok := common.CurrentParty(c.gameServer, requestData.UserId); ok && errs == nil && !c.autoLeaveParty {
+			errs = append(errs, errors.New("user "+requestData.UserId+" is already in party "+partyId+". exit it before joining another one"))
+		}
+
+		c.gameServer.Mutex.Unlock()
+	}
+
+	if errs == nil && friendsOnly {
+		if areFriends, err := c.mongoDAO.CheckFriendship(ctx, leaderId, []string{requestData.UserId}); err != nil || !areFriends {
+			errs = append(errs, errors.New("user "+requestData.UserId+" is not a friend of the party leader "+leaderId))
 		}
 	}
 
@@ -119,6 +173,9 @@ func JoinGamePartyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
 	fmt.Printf("Request data: %+v\n", requestData)
 
 	svc := GetJoinGamePartyService()
@@ -142,25 +199,101 @@ func JoinGamePartyHandler(w http.ResponseWriter, r *http.Request) {
 
 func (c joinGamePartyService) JoinGameParty(ctx context.Context, requestData *models.JoinGamePartyRequestData) error {
 
-	err := c.mongoDAO.UpdatePlayersDecisionForGameParty(ctx, requestData.PartyId, []string{requestData.UserId}, models.PlayerJoinedStatus)
+	var err error
+	if c.autoLeaveParty {
+		err = LeaveCurrentParty(ctx, c.gameServer, c.mongoDAO, c.eventBus, requestData.UserId)
+		if err != nil {
+			return err
+		}
+	}
+
+	// hold the user and the slot for this party so that a parallel create or join is rejected.
+	// The party may have filled up or left the lobby since the request was validated
+	c.gameServer.Mutex.Lock()
+	if currentPartyId, ok := common.CurrentParty(c.gameServer, requestData.UserId); ok {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("user " + requestData.UserId + " is already in party " + currentPartyId)
+	}
+	gameParty, ok := c.gameServer.Parties[requestData.PartyId]
+	if !ok {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("game party is over")
+	}
+	if gameParty.Status != models.GamePartyStatusActive {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("party " + requestData.PartyId + " has status: " + string(gameParty.Status) + ". players can only join in the lobby")
+	}
+	if capacity, slotsLeft := joinSlotsLeft(gameParty, requestData.UserId, c.maxPartySize); slotsLeft <= 0 {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("party " + requestData.PartyId + " is full. capacity: " + fmt.Sprint(capacity) + " players, 0 slots left")
+	}
+	// users joining a friends or open party directly may not be players yet
+	oldPlayerStatus, isPlayer := gameParty.Players[requestData.UserId]
+	if gameParty.Players == nil {
+		gameParty.Players = make(map[string]models.GamePartyPlayerStatus)
+	}
+	gameParty.Players[requestData.UserId] = models.PlayerJoinedStatus
+	c.gameServer.UserParties[requestData.UserId] = requestData.PartyId
+	c.gameServer.Mutex.Unlock()
+
+	releaseUser := func() {
+		c.gameServer.Mutex.Lock()
+		if gameParty.Players[requestData.UserId] == models.PlayerJoinedStatus {
+			if isPlayer {
+				gameParty.Players[requestData.UserId] = oldPlayerStatus
+			} else {
+				delete(gameParty.Players, requestData.UserId)
+			}
+		}
+		common.LeaveParty(c.gameServer, requestData.UserId, requestData.PartyId)
+		c.gameServer.Mutex.Unlock()
+	}
+
+	if isPlayer {
+		err = c.mongoDAO.UpdatePlayersDecisionForGameParty(ctx, requestData.PartyId, []string{requestData.UserId}, models.PlayerJoinedStatus)
+	} else {
+		err = c.mongoDAO.AddPlayerToGameParty(ctx, requestData.PartyId, requestData.UserId, models.PlayerJoinedStatus)
+	}
 	if err != nil {
+		releaseUser()
 		return err
 	}
 
-	_, err = c.mongoDAO.UpdateUsersStatus(ctx, []string{requestData.UserId}, models.UserStatusInGame)
+	// used to find the longest joined player when the leader leaves
+	joinedAt := time.Now()
+	err = c.mongoDAO.UpdatePlayerJoinedAt(ctx, requestData.PartyId, requestData.UserId, joinedAt)
 	if err != nil {
+		releaseUser()
 		return err
 	}
 
 	c.gameServer.Mutex.Lock()
-	c.gameServer.Parties[requestData.PartyId].Players[requestData.UserId] = models.PlayerJoinedStatus
+	if _, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
+		// party ended while the user was joining
+		common.LeaveParty(c.gameServer, requestData.UserId, requestData.PartyId)
+		c.gameServer.Mutex.Unlock()
+		return errors.New("game party is over")
+	}
+	if gameParty.JoinedAt == nil {
+		gameParty.JoinedAt = make(map[string]time.Time)
+	}
+	gameParty.JoinedAt[requestData.UserId] = joinedAt
+	c.gameServer.Mutex.Unlock()
 
-	// if channel is initialized to listen for any player joining the game
-	if c.gameServer.Parties[requestData.PartyId].PlayerStatusUpdateMsg != nil {
-		c.gameServer.Parties[requestData.PartyId].PlayerStatusUpdateMsg <- requestData.UserId + " has " + string(models.PlayerJoinedStatus) + " the party"
+	err = UpdateUsersStatusFromMembership(ctx, c.gameServer, c.mongoDAO, c.eventBus, []string{requestData.UserId})
+	if err != nil {
+		return err
 	}
 
-	c.gameServer.Mutex.Unlock()
+	// let everyone listening to the party know
+	c.eventBus.Publish(eventbus.PartyTopic(requestData.PartyId), &models.PresenceEvent{
+		Type:        models.PresenceEventPlayerJoinedParty,
+		ActorUserId: requestData.UserId,
+		PartyId:     requestData.PartyId,
+		OldStatus:   string(oldPlayerStatus),
+		NewStatus:   string(models.PlayerJoinedStatus),
+		Timestamp:   time.Now(),
+	})
 
 	return nil
 }
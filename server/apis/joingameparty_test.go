@@ -0,0 +1,204 @@
+package apis
+
+import (
+	"context"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/eventbus"
+	"sync"
+	"testing"
+)
+
+func TestJoinGamePartyCapacity(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility models.GamePartyVisibility
+		capacity   int
+		players    map[string]models.GamePartyPlayerStatus
+		wantJoined bool
+	}{
+		{
+			name:       "accepted player joins the last slot",
+			capacity:   3,
+			players:    map[string]models.GamePartyPlayerStatus{"p1": models.PlayerJoinedStatus, "u1": models.PlayerAcceptedStatus},
+			wantJoined: true,
+		},
+		{
+			name:     "accepted player cannot join a full party",
+			capacity: 2,
+			players:  map[string]models.GamePartyPlayerStatus{"p1": models.PlayerJoinedStatus, "u1": models.PlayerAcceptedStatus},
+		},
+		{
+			name:       "friend cannot take the slot of a pending invitation",
+			visibility: models.GamePartyVisibilityFriends,
+			capacity:   3,
+			players:    map[string]models.GamePartyPlayerStatus{"p1": models.PlayerJoinedStatus, "p2": models.PlayerInvitedStatus},
+		},
+		{
+			name:       "invited friend joining directly uses up their own invitation",
+			visibility: models.GamePartyVisibilityFriends,
+			capacity:   3,
+			players:    map[string]models.GamePartyPlayerStatus{"p1": models.PlayerJoinedStatus, "u1": models.PlayerInvitedStatus},
+			wantJoined: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mongoDAO := mongodao.NewInMemoryDao()
+			createTestUsers(t, mongoDAO, "leader", "u1", "p1", "p2")
+			befriend(t, mongoDAO, "leader", "u1", "p1", "p2")
+			gameServer := newTestGameServer(t, mongoDAO)
+			storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Capacity: tt.capacity, Visibility: tt.visibility, Players: tt.players})
+
+			svc := joinGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), maxPartySize: 8}
+			requestData := &models.JoinGamePartyRequestData{PartyId: "party1", UserId: "u1"}
+			errs := svc.ValidateRequest(ctx, requestData)
+			if (errs == nil) != tt.wantJoined {
+				t.Fatalf("ValidateRequest = %v, want joined %v", errs, tt.wantJoined)
+			}
+			if errs != nil {
+				return
+			}
+			if err := svc.JoinGameParty(ctx, requestData); err != nil {
+				t.Fatalf("JoinGameParty: %v", err)
+			}
+			if playerStatus := storedPlayerStatus(t, gameServer, mongoDAO, "party1", "u1"); playerStatus != models.PlayerJoinedStatus {
+				t.Errorf("player status = %v, want %v", playerStatus, models.PlayerJoinedStatus)
+			}
+		})
+	}
+}
+
+func TestJoinGamePartyConcurrentJoinsKeepCapacity(t *testing.T) {
+	ctx := context.TODO()
+	mongoDAO := mongodao.NewInMemoryDao()
+	createTestUsers(t, mongoDAO, "leader", "u1", "u2", "u3")
+	gameServer := newTestGameServer(t, mongoDAO)
+	storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Capacity: 2, Players: map[string]models.GamePartyPlayerStatus{
+		"u1": models.PlayerAcceptedStatus,
+		"u2": models.PlayerAcceptedStatus,
+		"u3": models.PlayerAcceptedStatus,
+	}})
+	svc := joinGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), maxPartySize: 8}
+
+	// every join passed validation before any of them was stored
+	var wg sync.WaitGroup
+	for _, userId := range []string{"u1", "u2", "u3"} {
+		wg.Add(1)
+		go func(userId string) {
+			defer wg.Done()
+			svc.JoinGameParty(ctx, &models.JoinGamePartyRequestData{PartyId: "party1", UserId: userId})
+		}(userId)
+	}
+	wg.Wait()
+
+	joined := 0
+	for _, userId := range []string{"u1", "u2", "u3"} {
+		if storedPlayerStatus(t, gameServer, mongoDAO, "party1", userId) == models.PlayerJoinedStatus {
+			joined++
+		}
+	}
+	if joined != 1 {
+		t.Errorf("%v players joined a party with room for 1", joined)
+	}
+}
+
+func TestJoinGamePartyOnePartyAtATime(t *testing.T) {
+	tests := []struct {
+		name           string
+		autoLeaveParty bool
+		wantJoined     bool
+	}{
+		{name: "user in another party is rejected"},
+		{name: "user in another party leaves it with auto_leave_party", autoLeaveParty: true, wantJoined: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mongoDAO := mongodao.NewInMemoryDao()
+			createTestUsers(t, mongoDAO, "leaderA", "leaderB", "u1")
+			gameServer := newTestGameServer(t, mongoDAO)
+			storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "partyA", CreatedBy: "leaderA", Players: map[string]models.GamePartyPlayerStatus{"u1": models.PlayerJoinedStatus}})
+			storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "partyB", CreatedBy: "leaderB", Players: map[string]models.GamePartyPlayerStatus{"u1": models.PlayerAcceptedStatus}})
+
+			svc := joinGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), maxPartySize: 8, autoLeaveParty: tt.autoLeaveParty}
+			requestData := &models.JoinGamePartyRequestData{PartyId: "partyB", UserId: "u1"}
+			errs := svc.ValidateRequest(ctx, requestData)
+			if (errs == nil) != tt.wantJoined {
+				t.Fatalf("ValidateRequest = %v, want joined %v", errs, tt.wantJoined)
+			}
+
+			wantPartyId, wantStatusA := "partyA", models.PlayerJoinedStatus
+			if errs == nil {
+				if err := svc.JoinGameParty(ctx, requestData); err != nil {
+					t.Fatalf("JoinGameParty: %v", err)
+				}
+				wantPartyId, wantStatusA = "partyB", models.PlayerExitedStatus
+			}
+
+			if playerStatus := storedPlayerStatus(t, gameServer, mongoDAO, "partyA", "u1"); playerStatus != wantStatusA {
+				t.Errorf("status in the previous party = %v, want %v", playerStatus, wantStatusA)
+			}
+			gameServer.Mutex.Lock()
+			partyId := gameServer.UserParties["u1"]
+			gameServer.Mutex.Unlock()
+			if partyId != wantPartyId {
+				t.Errorf("user is in party %v, want %v", partyId, wantPartyId)
+			}
+		})
+	}
+}
+
+func TestJoinGamePartyVisibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility models.GamePartyVisibility
+		partyId    string
+		joinCode   string
+		friendOfU1 bool
+		wantJoined bool
+	}{
+		{name: "open party with its join code", visibility: models.GamePartyVisibilityOpen, joinCode: "ABC123", wantJoined: true},
+		{name: "open party with a wrong join code", visibility: models.GamePartyVisibilityOpen, partyId: "party1", joinCode: "WRONG1"},
+		{name: "open party without the join code", visibility: models.GamePartyVisibilityOpen, partyId: "party1"},
+		{name: "friends party by a friend of the leader", visibility: models.GamePartyVisibilityFriends, partyId: "party1", friendOfU1: true, wantJoined: true},
+		{name: "friends party by someone else", visibility: models.GamePartyVisibilityFriends, partyId: "party1"},
+		{name: "invite-only party without an invitation", visibility: models.GamePartyVisibilityInviteOnly, partyId: "party1", friendOfU1: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mongoDAO := mongodao.NewInMemoryDao()
+			createTestUsers(t, mongoDAO, "leader", "u1")
+			if tt.friendOfU1 {
+				befriend(t, mongoDAO, "leader", "u1")
+			}
+			gameServer := newTestGameServer(t, mongoDAO)
+			gameParty := &models.GameParty{PartyId: "party1", CreatedBy: "leader", Capacity: 4, Visibility: tt.visibility}
+			if tt.visibility == models.GamePartyVisibilityOpen {
+				gameParty.JoinCode = "ABC123"
+			}
+			storeTestParty(t, gameServer, mongoDAO, gameParty)
+
+			svc := joinGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), maxPartySize: 8}
+			requestData := &models.JoinGamePartyRequestData{PartyId: tt.partyId, UserId: "u1", JoinCode: tt.joinCode}
+			errs := svc.ValidateRequest(ctx, requestData)
+			if (errs == nil) != tt.wantJoined {
+				t.Fatalf("ValidateRequest = %v, want joined %v", errs, tt.wantJoined)
+			}
+			if errs != nil {
+				return
+			}
+			if err := svc.JoinGameParty(ctx, requestData); err != nil {
+				t.Fatalf("JoinGameParty: %v", err)
+			}
+			if playerStatus := storedPlayerStatus(t, gameServer, mongoDAO, "party1", "u1"); playerStatus != models.PlayerJoinedStatus {
+				t.Errorf("player status = %v, want %v", playerStatus, models.PlayerJoinedStatus)
+			}
+		})
+	}
+}
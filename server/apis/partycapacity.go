@@ -0,0 +1,47 @@
+package apis
+
+import "lite-social-presence-system/models"
+
+// most players the party can have, leader included.
+// Parties created before capacities existed get the max party size
+func partyCapacity(gameParty *models.GameParty, maxPartySize int) int {
+	if gameParty.Capacity > 0 {
+		return gameParty.Capacity
+	}
+	return maxPartySize
+}
+
+// number of players, leader not included, having one of the statuses
+func countPlayers(gameParty *models.GameParty, statuses ...models.GamePartyPlayerStatus) int {
+	count := 0
+	for _, playerStatus := range gameParty.Players {
+		for _, status := range statuses {
+			if playerStatus == status {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+// slots left for new invitations. Pending invitations hold a slot until they are rejected
+func inviteSlotsLeft(gameParty *models.GameParty, maxPartySize int) (int, int) {
+	capacity := partyCapacity(gameParty, maxPartySize)
+	return capacity, capacity - 1 - countPlayers(gameParty, models.PlayerInvitedStatus, models.PlayerAcceptedStatus, models.PlayerJoinedStatus)
+}
+
+// slots left for the user to join. A player who accepted the invitation already holds a slot.
+// Users joining directly also have to leave the slots of the pending invitations, their own invitation is used up by joining
+func joinSlotsLeft(gameParty *models.GameParty, userId string, maxPartySize int) (int, int) {
+	capacity := partyCapacity(gameParty, maxPartySize)
+	playerStatus := gameParty.Players[userId]
+	if playerStatus == models.PlayerAcceptedStatus {
+		return capacity, capacity - 1 - countPlayers(gameParty, models.PlayerJoinedStatus)
+	}
+	_, slotsLeft := inviteSlotsLeft(gameParty, maxPartySize)
+	if playerStatus == models.PlayerInvitedStatus {
+		slotsLeft++
+	}
+	return capacity, slotsLeft
+}
@@ -0,0 +1,168 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+type PartyChatService interface {
+	CheckMember(partyId string, userId string) error
+	GetHistory(ctx context.Context, partyId string) []*models.ChatMessage
+	SendMessage(ctx context.Context, partyId string, userId string, text string) error
+}
+
+var partyChatServiceStruct PartyChatService
+var partyChatServiceOnce sync.Once
+
+type partyChatService struct {
+	gameServer     *models.GameServer
+	mongoDAO       mongodao.MongoDAO
+	eventBus       eventbus.EventBus
+	historySize    int
+	maxMessageSize int
+	persist        bool
+}
+
+func InitPartyChatService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, historySz int, maxMessageSz int, persistChat bool) PartyChatService {
+	partyChatServiceOnce.Do(func() {
+		partyChatServiceStruct = &partyChatService{
+			gameServer:     gameSrvr,
+			mongoDAO:       mongodao,
+			eventBus:       evntBus,
+			historySize:    historySz,
+			maxMessageSize: maxMessageSz,
+			persist:        persistChat,
+		}
+	})
+	return partyChatServiceStruct
+}
+
+func GetPartyChatService() PartyChatService {
+	if partyChatServiceStruct == nil {
+		panic("PartyChat Service not initialized")
+	}
+	return partyChatServiceStruct
+}
+
+// only the leader and the joined players can use the party chat
+func (c partyChatService) CheckMember(partyId string, userId string) error {
+	c.gameServer.Mutex.Lock()
+	defer c.gameServer.Mutex.Unlock()
+
+	return checkPartyChatMember(c.gameServer, partyId, userId)
+}
+
+// gameServer.Mutex has to be held
+func checkPartyChatMember(gameServer *models.GameServer, partyId string, userId string) error {
+	gameParty, ok := gameServer.Parties[partyId]
+	if !ok {
+		return errors.New("party " + partyId + " not found")
+	}
+	if common.PartyLeader(gameParty) != userId && gameParty.Players[userId] != models.PlayerJoinedStatus {
+		return errors.New("user " + userId + " is neither the party leader nor has joined party " + partyId)
+	}
+	return nil
+}
+
+// latest messages of the party, oldest first. Stored messages are loaded after a restart
+func (c partyChatService) GetHistory(ctx context.Context, partyId string) []*models.ChatMessage {
+
+	history, err := c.loadHistory(ctx, partyId)
+	if err != nil {
+		fmt.Println("Failed to fetch the party chat history", err)
+	}
+
+	// messages are never changed once stored, copying the slice is enough
+	return append([]*models.ChatMessage(nil), history...)
+}
+
+// history of the party, loaded from the stored messages the first time the chat is used after a restart.
+// Has to run before a new message is stored so that it is not loaded and appended twice
+func (c partyChatService) loadHistory(ctx context.Context, partyId string) ([]*models.ChatMessage, error) {
+
+	c.gameServer.Mutex.Lock()
+	history, ok := c.gameServer.ChatHistory[partyId]
+	c.gameServer.Mutex.Unlock()
+	if ok || !c.persist {
+		return history, nil
+	}
+
+	chatMessages, err := c.mongoDAO.FetchPartyChatMessages(ctx, partyId, c.historySize)
+	if err != nil {
+		return nil, err
+	}
+
+	c.gameServer.Mutex.Lock()
+	defer c.gameServer.Mutex.Unlock()
+	// loaded in the meantime by another player of the party
+	if _, ok := c.gameServer.ChatHistory[partyId]; !ok {
+		if _, active := c.gameServer.Parties[partyId]; active {
+			c.gameServer.ChatHistory[partyId] = chatMessages
+		}
+	}
+	return c.gameServer.ChatHistory[partyId], nil
+}
+
+func (c partyChatService) SendMessage(ctx context.Context, partyId string, userId string, text string) error {
+
+	if strings.TrimSpace(text) == literals.EmptyString {
+		return errors.New("empty chat message")
+	}
+	if !utf8.ValidString(text) {
+		return errors.New("chat message is not valid UTF-8")
+	}
+	if len(text) > c.maxMessageSize {
+		return errors.New("chat message is " + fmt.Sprint(len(text)) + " bytes. at most " + fmt.Sprint(c.maxMessageSize) + " bytes allowed")
+	}
+
+	if err := c.CheckMember(partyId, userId); err != nil {
+		return err
+	}
+
+	chatMessage := &models.ChatMessage{
+		MessageId: uuid.NewString(),
+		PartyId:   partyId,
+		UserId:    userId,
+		Text:      text,
+		SentAt:    time.Now(),
+	}
+
+	if c.persist {
+		// messages sent before a restart stay in the history
+		if _, err := c.loadHistory(ctx, partyId); err != nil {
+			return err
+		}
+		err := c.mongoDAO.InsertPartyChatMessage(ctx, chatMessage)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.gameServer.Mutex.Lock()
+	if _, ok := c.gameServer.Parties[partyId]; !ok {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("game party is over")
+	}
+	history := append(c.gameServer.ChatHistory[partyId], chatMessage)
+	if len(history) > c.historySize {
+		history = history[len(history)-c.historySize:]
+	}
+	c.gameServer.ChatHistory[partyId] = history
+	c.gameServer.Mutex.Unlock()
+
+	c.eventBus.Publish(eventbus.ChatTopic(partyId), &models.ChatMessageEvent{Message: chatMessage})
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package apis
+
+import (
+	"context"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/eventbus"
+	"strings"
+	"testing"
+)
+
+func TestPartyChatMembership(t *testing.T) {
+	tests := []struct {
+		name       string
+		userId     string
+		wantMember bool
+	}{
+		{name: "leader", userId: "leader", wantMember: true},
+		{name: "joined player", userId: "p1", wantMember: true},
+		{name: "accepted player who has not joined", userId: "p2"},
+		{name: "invited player", userId: "p3"},
+		{name: "user outside the party", userId: "u1"},
+	}
+
+	ctx := context.TODO()
+	mongoDAO := mongodao.NewInMemoryDao()
+	createTestUsers(t, mongoDAO, "leader", "p1", "p2", "p3", "u1")
+	gameServer := newTestGameServer(t, mongoDAO)
+	storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Players: map[string]models.GamePartyPlayerStatus{
+		"p1": models.PlayerJoinedStatus,
+		"p2": models.PlayerAcceptedStatus,
+		"p3": models.PlayerInvitedStatus,
+	}})
+	svc := partyChatService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), historySize: 10, maxMessageSize: 100}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.CheckMember("party1", tt.userId); (err == nil) != tt.wantMember {
+				t.Errorf("CheckMember = %v, want member %v", err, tt.wantMember)
+			}
+			if err := svc.SendMessage(ctx, "party1", tt.userId, "hi"); (err == nil) != tt.wantMember {
+				t.Errorf("SendMessage = %v, want sent %v", err, tt.wantMember)
+			}
+		})
+	}
+}
+
+func TestPartyChatMessageSize(t *testing.T) {
+	maxMessageSize := 10
+
+	tests := []struct {
+		name     string
+		text     string
+		wantSent bool
+	}{
+		{name: "message at the size limit", text: strings.Repeat("a", maxMessageSize), wantSent: true},
+		{name: "message over the size limit", text: strings.Repeat("a", maxMessageSize+1)},
+		{name: "size is counted in bytes", text: strings.Repeat("é", maxMessageSize/2+1)},
+		{name: "blank message", text: " \n\t"},
+		{name: "invalid UTF-8", text: "a\xffb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mongoDAO := mongodao.NewInMemoryDao()
+			createTestUsers(t, mongoDAO, "leader")
+			gameServer := newTestGameServer(t, mongoDAO)
+			storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader"})
+			svc := partyChatService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), historySize: 10, maxMessageSize: maxMessageSize}
+
+			err := svc.SendMessage(ctx, "party1", "leader", tt.text)
+			if (err == nil) != tt.wantSent {
+				t.Fatalf("SendMessage = %v, want sent %v", err, tt.wantSent)
+			}
+			wantHistory := 0
+			if tt.wantSent {
+				wantHistory = 1
+			}
+			if history := svc.GetHistory(ctx, "party1"); len(history) != wantHistory {
+				t.Errorf("history has %v messages, want %v", len(history), wantHistory)
+			}
+		})
+	}
+}
+
+func TestPartyChatHistory(t *testing.T) {
+	tests := []struct {
+		name        string
+		persist     bool
+		wantHistory []string
+	}{
+		{name: "stored messages are kept after a restart", persist: true, wantHistory: []string{"2", "3", "4"}},
+		{name: "messages are lost after a restart when not stored", wantHistory: []string{"4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mongoDAO := mongodao.NewInMemoryDao()
+			createTestUsers(t, mongoDAO, "leader")
+			gameServer := newTestGameServer(t, mongoDAO)
+			storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader"})
+			svc := partyChatService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), historySize: 3, maxMessageSize: 100, persist: tt.persist}
+			for _, text := range []string{"1", "2", "3"} {
+				if err := svc.SendMessage(ctx, "party1", "leader", text); err != nil {
+					t.Fatalf("SendMessage: %v", err)
+				}
+			}
+
+			// restart: the history in memory is gone, the party is loaded again
+			gameServer.Mutex.Lock()
+			gameServer.ChatHistory = make(map[string][]*models.ChatMessage)
+			gameServer.Mutex.Unlock()
+			if err := svc.SendMessage(ctx, "party1", "leader", "4"); err != nil {
+				t.Fatalf("SendMessage: %v", err)
+			}
+
+			var history []string
+			for _, chatMessage := range svc.GetHistory(ctx, "party1") {
+				history = append(history, chatMessage.Text)
+			}
+			if strings.Join(history, ",") != strings.Join(tt.wantHistory, ",") {
+				t.Errorf("history = %v, want %v", history, tt.wantHistory)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type PartyInvitationsService interface {
+	GetPendingInvitations(ctx context.Context, userId string) []*models.PartyInvitation
+}
+
+var partyInvitationsServiceStruct PartyInvitationsService
+var partyInvitationsServiceOnce sync.Once
+
+type partyInvitationsService struct {
+	gameServer *models.GameServer
+	inviteTTL  time.Duration
+}
+
+func InitPartyInvitationsService(gameSrvr *models.GameServer, invitationTTL time.Duration) PartyInvitationsService {
+	partyInvitationsServiceOnce.Do(func() {
+		partyInvitationsServiceStruct = &partyInvitationsService{
+			gameServer: gameSrvr,
+			inviteTTL:  invitationTTL,
+		}
+	})
+	return partyInvitationsServiceStruct
+}
+
+func GetPartyInvitationsServiceStruct() PartyInvitationsService {
+	if partyInvitationsServiceStruct == nil {
+		panic("PartyInvitations Service not initialized")
+	}
+	return partyInvitationsServiceStruct
+}
+
+// GET pending Party Invitations
+func GetPartyInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := context.TODO()
+
+	var invitations []*models.PartyInvitation
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+
+	defer func() {
+		result := models.GetPartyInvitationsResponseData{
+			Success:     success,
+			Invitations: invitations,
+			Errors:      errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	// invitations are always fetched for the authenticated caller
+	userId, _ := auth.UserIdFromContext(r.Context())
+	fmt.Println("Request data: ", userId)
+
+	if userId == literals.EmptyString {
+		err := errors.New("no user ID passed")
+
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	svc := GetPartyInvitationsServiceStruct()
+	invitations = svc.GetPendingInvitations(ctx, userId)
+	if invitations == nil {
+		invitations = []*models.PartyInvitation{}
+	}
+	fmt.Printf("Found %d pending invitations\n", len(invitations))
+}
+
+// invitations of the active parties the user has neither accepted nor rejected and that have not expired. Oldest first
+func (c partyInvitationsService) GetPendingInvitations(ctx context.Context, userId string) []*models.PartyInvitation {
+
+	now := time.Now()
+	var invitations []*models.PartyInvitation
+
+	c.gameServer.Mutex.Lock()
+	for partyId, gameParty := range c.gameServer.Parties {
+		if gameParty.Players[userId] != models.PlayerInvitedStatus {
+			continue
+		}
+		expiresAt := invitationExpiresAt(gameParty, userId, c.inviteTTL)
+		if !now.Before(expiresAt) {
+			// expired, waiting for the next sweep
+			continue
+		}
+		leaderId := common.PartyLeader(gameParty)
+		invitedBy := gameParty.InvitedBy[userId]
+		if invitedBy == literals.EmptyString {
+			// invited before the inviter was recorded. Only the leader could invite
+			invitedBy = leaderId
+		}
+		invitations = append(invitations, &models.PartyInvitation{
+			PartyId:      partyId,
+			InvitedBy:    invitedBy,
+			InvitedAt:    gameParty.InvitedAt[userId],
+			ExpiresAt:    expiresAt,
+			Leader:       leaderId,
+			PartyEndTime: gameParty.StartTime.Add(gameParty.Duration),
+		})
+	}
+	c.gameServer.Mutex.Unlock()
+
+	sort.Slice(invitations, func(i, j int) bool {
+		if !invitations[i].InvitedAt.Equal(invitations[j].InvitedAt) {
+			return invitations[i].InvitedAt.Before(invitations[j].InvitedAt)
+		}
+		return invitations[i].PartyId < invitations[j].PartyId
+	})
+	return invitations
+}
+
+// time after which the invitation of the user can no longer be accepted.
+// Invitations sent before the invitation time was recorded are already expired
+func invitationExpiresAt(gameParty *models.GameParty, userId string, inviteTTL time.Duration) time.Time {
+	return gameParty.InvitedAt[userId].Add(inviteTTL)
+}
+
+// mark the invitations of every active party that were not answered within inviteTTL as expired
+// and let the party and the invitees know. Called periodically
+func ExpirePartyInvitations(ctx context.Context, gameServer *models.GameServer, mongoDAO mongodao.MongoDAO, eventBus eventbus.EventBus, inviteTTL time.Duration) error {
+
+	now := time.Now()
+	expiredInviteeIds := make(map[string][]string)
+
+	gameServer.Mutex.Lock()
+	for partyId, gameParty := range gameServer.Parties {
+		for playerId, playerStatus := range gameParty.Players {
+			if playerStatus == models.PlayerInvitedStatus && !now.Before(invitationExpiresAt(gameParty, playerId, inviteTTL)) {
+				expiredInviteeIds[partyId] = append(expiredInviteeIds[partyId], playerId)
+			}
+		}
+	}
+	gameServer.Mutex.Unlock()
+
+	for partyId, inviteeIds := range expiredInviteeIds {
+		logrus.WithFields(logrus.Fields{
+			literals.LLPartyId:           partyId,
+			literals.LLExpiredInviteeIds: inviteeIds,
+			literals.LLInviteTTL:         inviteTTL,
+		}).Info("Expiring party invitations")
+
+		err := closeInvitations(ctx, gameServer, mongoDAO, eventBus, partyId, literals.EmptyString, inviteeIds, models.PlayerExpiredStatus, models.PlayerInvitedStatus)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// move the invitations of the players to expired or cancelled and publish it on the party topic and the invitees' inboxes.
+// actorId is the leader cancelling the invitations, empty when they expire.
+// Only players still having one of fromStatuses are moved, the others have answered in the meantime
+func closeInvitations(ctx context.Context, gameServer *models.GameServer, mongoDAO mongodao.MongoDAO, eventBus eventbus.EventBus, partyId string, actorId string, playerIds []string, status models.GamePartyPlayerStatus, fromStatuses ...models.GamePartyPlayerStatus) error {
+
+	eventType := models.PresenceEventPartyInviteExpired
+	if status == models.PlayerCancelledStatus {
+		eventType = models.PresenceEventPartyInviteCancelled
+	}
+
+	var closedPlayerIds []string
+	var events []*models.PresenceEvent
+	gameServer.Mutex.Lock()
+	if gameParty, ok := gameServer.Parties[partyId]; ok {
+		for _, playerId := range playerIds {
+			oldStatus := gameParty.Players[playerId]
+			if !slices.Contains(fromStatuses, oldStatus) {
+				continue
+			}
+			gameParty.Players[playerId] = status
+			closedPlayerIds = append(closedPlayerIds, playerId)
+			events = append(events, &models.PresenceEvent{
+				Type:         eventType,
+				ActorUserId:  actorId,
+				TargetUserId: playerId,
+				PartyId:      partyId,
+				OldStatus:    string(oldStatus),
+				NewStatus:    string(status),
+				Timestamp:    time.Now(),
+			})
+		}
+	}
+	gameServer.Mutex.Unlock()
+
+	if len(closedPlayerIds) == 0 {
+		return nil
+	}
+
+	err := mongoDAO.UpdatePlayersDecisionForGameParty(ctx, partyId, closedPlayerIds, status)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		eventBus.Publish(eventbus.PartyTopic(partyId), event)
+		eventBus.Publish(eventbus.InvitationTopic(event.TargetUserId), event)
+	}
+	return nil
+}
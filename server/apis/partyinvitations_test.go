@@ -0,0 +1,119 @@
+package apis
+
+import (
+	"context"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/eventbus"
+	"testing"
+	"time"
+)
+
+func TestExpirePartyInvitations(t *testing.T) {
+	inviteTTL := time.Minute
+
+	tests := []struct {
+		name       string
+		status     models.GamePartyPlayerStatus
+		invitedAgo time.Duration
+		wantStatus models.GamePartyPlayerStatus
+	}{
+		{name: "pending invitation older than the ttl expires", status: models.PlayerInvitedStatus, invitedAgo: 2 * inviteTTL, wantStatus: models.PlayerExpiredStatus},
+		{name: "pending invitation within the ttl stays", status: models.PlayerInvitedStatus, invitedAgo: inviteTTL / 2, wantStatus: models.PlayerInvitedStatus},
+		{name: "accepted invitation never expires", status: models.PlayerAcceptedStatus, invitedAgo: 2 * inviteTTL, wantStatus: models.PlayerAcceptedStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mongoDAO := mongodao.NewInMemoryDao()
+			createTestUsers(t, mongoDAO, "leader", "u1")
+			gameServer := newTestGameServer(t, mongoDAO)
+			gameParty := storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Players: map[string]models.GamePartyPlayerStatus{"u1": tt.status}})
+			gameParty.InvitedAt["u1"] = time.Now().Add(-tt.invitedAgo)
+
+			if err := ExpirePartyInvitations(ctx, gameServer, mongoDAO, eventbus.NewEventBus(), inviteTTL); err != nil {
+				t.Fatalf("ExpirePartyInvitations: %v", err)
+			}
+			if playerStatus := storedPlayerStatus(t, gameServer, mongoDAO, "party1", "u1"); playerStatus != tt.wantStatus {
+				t.Errorf("player status = %v, want %v", playerStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandleExpiredInvitation(t *testing.T) {
+	ctx := context.TODO()
+	inviteTTL := time.Minute
+	mongoDAO := mongodao.NewInMemoryDao()
+	createTestUsers(t, mongoDAO, "leader", "u1")
+	gameServer := newTestGameServer(t, mongoDAO)
+	gameParty := storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Players: map[string]models.GamePartyPlayerStatus{"u1": models.PlayerInvitedStatus}})
+	// the sweeper has not run yet
+	gameParty.InvitedAt["u1"] = time.Now().Add(-2 * inviteTTL)
+
+	svc := handleGamePartyInviteService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), inviteTTL: inviteTTL}
+	requestData := &models.HandleGamePartyInviteRequestData{PartyId: "party1", UserId: "u1", Status: models.PlayerAcceptedStatus}
+	if errs := svc.ValidateRequest(ctx, requestData); errs == nil {
+		t.Errorf("ValidateRequest accepted an expired invitation")
+	}
+	if err := svc.HandleInvitationToGameParty(ctx, requestData); err == nil {
+		t.Errorf("HandleInvitationToGameParty accepted an expired invitation")
+	}
+	if playerStatus := storedPlayerStatus(t, gameServer, mongoDAO, "party1", "u1"); playerStatus != models.PlayerInvitedStatus {
+		t.Errorf("player status = %v, want %v", playerStatus, models.PlayerInvitedStatus)
+	}
+}
+
+func TestCancelAndAcceptInvitation(t *testing.T) {
+	tests := []struct {
+		name        string
+		cancelFirst bool
+		wantAccept  bool
+		wantStatus  models.GamePartyPlayerStatus
+	}{
+		{name: "cancelled invitation cannot be accepted", cancelFirst: true, wantStatus: models.PlayerCancelledStatus},
+		{name: "accepted invitation can still be cancelled before joining", wantAccept: true, wantStatus: models.PlayerCancelledStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mongoDAO := mongodao.NewInMemoryDao()
+			createTestUsers(t, mongoDAO, "leader", "u1")
+			gameServer := newTestGameServer(t, mongoDAO)
+			storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Players: map[string]models.GamePartyPlayerStatus{"u1": models.PlayerInvitedStatus}})
+
+			eventBus := eventbus.NewEventBus()
+			handleSvc := handleGamePartyInviteService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventBus, inviteTTL: time.Hour}
+			cancelSvc := cancelGamePartyInviteService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventBus}
+			acceptData := &models.HandleGamePartyInviteRequestData{PartyId: "party1", UserId: "u1", Status: models.PlayerAcceptedStatus}
+			cancelData := &models.CancelGamePartyInviteRequestData{PartyId: "party1", UserId: "leader", FriendIds: []string{"u1"}}
+
+			cancel := func() {
+				if errs := cancelSvc.ValidateRequest(ctx, cancelData); errs != nil {
+					t.Fatalf("cancel ValidateRequest: %v", errs)
+				}
+				if err := cancelSvc.CancelInvitations(ctx, cancelData); err != nil {
+					t.Fatalf("CancelInvitations: %v", err)
+				}
+			}
+
+			if tt.cancelFirst {
+				cancel()
+			}
+			// the accept was validated before the cancel landed
+			err := handleSvc.HandleInvitationToGameParty(ctx, acceptData)
+			if (err == nil) != tt.wantAccept {
+				t.Fatalf("HandleInvitationToGameParty = %v, want accepted %v", err, tt.wantAccept)
+			}
+			if !tt.cancelFirst {
+				cancel()
+			}
+
+			if playerStatus := storedPlayerStatus(t, gameServer, mongoDAO, "party1", "u1"); playerStatus != tt.wantStatus {
+				t.Errorf("player status = %v, want %v", playerStatus, tt.wantStatus)
+			}
+		})
+	}
+}
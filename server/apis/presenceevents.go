@@ -0,0 +1,116 @@
+package apis
+
+import (
+	"fmt"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/protos/gampepb"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var presenceEventTypeToProto = map[models.PresenceEventType]gampepb.PresenceEventType{
+	models.PresenceEventUserOnline:           gampepb.PresenceEventType_USER_ONLINE,
+	models.PresenceEventUserOffline:          gampepb.PresenceEventType_USER_OFFLINE,
+	models.PresenceEventUserStatusChanged:    gampepb.PresenceEventType_USER_STATUS_CHANGED,
+	models.PresenceEventPlayerJoinedParty:    gampepb.PresenceEventType_PLAYER_JOINED_PARTY,
+	models.PresenceEventPlayerExitedParty:    gampepb.PresenceEventType_PLAYER_EXITED_PARTY,
+	models.PresenceEventPlayerRemoved:        gampepb.PresenceEventType_PLAYER_REMOVED,
+	models.PresenceEventPartyInviteSent:      gampepb.PresenceEventType_PARTY_INVITE_SENT,
+	models.PresenceEventPartyInviteAccepted:  gampepb.PresenceEventType_PARTY_INVITE_ACCEPTED,
+	models.PresenceEventPartyInviteRejected:  gampepb.PresenceEventType_PARTY_INVITE_REJECTED,
+	models.PresenceEventPartyEnded:           gampepb.PresenceEventType_PARTY_ENDED,
+	models.PresenceEventServerShuttingDown:   gampepb.PresenceEventType_SERVER_SHUTTING_DOWN,
+	models.PresenceEventPartyLeaderChanged:   gampepb.PresenceEventType_PARTY_LEADER_CHANGED,
+	models.PresenceEventPartyInviteExpired:   gampepb.PresenceEventType_PARTY_INVITE_EXPIRED,
+	models.PresenceEventPartyInviteCancelled: gampepb.PresenceEventType_PARTY_INVITE_CANCELLED,
+	models.PresenceEventPartyJoinRequested:   gampepb.PresenceEventType_PARTY_JOIN_REQUESTED,
+	models.PresenceEventReadyCheckStarted:    gampepb.PresenceEventType_READY_CHECK_STARTED,
+	models.PresenceEventReadyCheckResponded:  gampepb.PresenceEventType_READY_CHECK_RESPONDED,
+	models.PresenceEventPartyStatusChanged:   gampepb.PresenceEventType_PARTY_STATUS_CHANGED,
+}
+
+func toChatMessageProto(chatMessage *models.ChatMessage) *gampepb.PartyChatMessage {
+	return &gampepb.PartyChatMessage{
+		MessageId: chatMessage.MessageId,
+		PartyId:   chatMessage.PartyId,
+		UserId:    chatMessage.UserId,
+		Text:      chatMessage.Text,
+		SentAt:    timestamppb.New(chatMessage.SentAt),
+	}
+}
+
+func toDirectMessageProto(directMessage *models.DirectMessage) *gampepb.DirectMessage {
+	return &gampepb.DirectMessage{
+		MessageId:   directMessage.MessageId,
+		SenderId:    directMessage.SenderId,
+		RecipientId: directMessage.RecipientId,
+		Text:        directMessage.Text,
+		SentAt:      timestamppb.New(directMessage.SentAt),
+	}
+}
+
+func toPresenceEventProto(event *models.PresenceEvent) *gampepb.PresenceEvent {
+	return &gampepb.PresenceEvent{
+		Type:         presenceEventTypeToProto[event.Type], // unknown types map to PRESENCE_EVENT_TYPE_UNSPECIFIED
+		ActorUserId:  event.ActorUserId,
+		TargetUserId: event.TargetUserId,
+		PartyId:      event.PartyId,
+		OldStatus:    event.OldStatus,
+		NewStatus:    event.NewStatus,
+		Timestamp:    timestamppb.New(event.Timestamp),
+	}
+}
+
+// text of any event on the bus for the logs. The text of the messages is never logged
+func eventLogMessage(event models.Event) string {
+	switch event := event.(type) {
+	case *models.PresenceEvent:
+		return presenceEventMessage(event)
+	case *models.ChatMessageEvent:
+		return "chat message from " + event.Message.UserId
+	case *models.DirectMessageEvent:
+		return "direct message from " + event.Message.SenderId + " to " + event.Message.RecipientId
+	}
+	return event.EventType()
+}
+
+// human readable text of the event, still sent in the deprecated message field for older clients
+func presenceEventMessage(event *models.PresenceEvent) string {
+	switch event.Type {
+	case models.PresenceEventUserOnline:
+		return fmt.Sprintf("%v is now online", event.ActorUserId)
+	case models.PresenceEventUserOffline:
+		return fmt.Sprintf("%v is now offline", event.ActorUserId)
+	case models.PresenceEventUserStatusChanged:
+		return fmt.Sprintf("%v is now %v", event.ActorUserId, event.NewStatus)
+	case models.PresenceEventPlayerJoinedParty:
+		return event.ActorUserId + " has " + string(models.PlayerJoinedStatus) + " the party"
+	case models.PresenceEventPlayerExitedParty:
+		return event.ActorUserId + " has " + string(models.PlayerExitedStatus) + " the party"
+	case models.PresenceEventPlayerRemoved:
+		return event.TargetUserId + " has been " + string(models.PlayerRemovedStatus) + " from the party by " + event.ActorUserId
+	case models.PresenceEventPartyInviteSent:
+		return event.TargetUserId + " has been " + string(models.PlayerInvitedStatus) + " to the party by " + event.ActorUserId
+	case models.PresenceEventPartyInviteAccepted, models.PresenceEventPartyInviteRejected:
+		return event.ActorUserId + " has " + event.NewStatus + " the invitation to the party"
+	case models.PresenceEventPartyEnded:
+		return "party " + event.PartyId + " is over"
+	case models.PresenceEventServerShuttingDown:
+		return "server shutting down"
+	case models.PresenceEventPartyLeaderChanged:
+		return event.TargetUserId + " is now the party leader"
+	case models.PresenceEventPartyInviteExpired:
+		return "invitation of " + event.TargetUserId + " to the party has " + string(models.PlayerExpiredStatus)
+	case models.PresenceEventPartyInviteCancelled:
+		return "invitation of " + event.TargetUserId + " to the party has been " + string(models.PlayerCancelledStatus) + " by " + event.ActorUserId
+	case models.PresenceEventPartyJoinRequested:
+		return event.ActorUserId + " asked " + event.TargetUserId + " for an invitation to the party"
+	case models.PresenceEventReadyCheckStarted:
+		return event.ActorUserId + " started a ready-check"
+	case models.PresenceEventReadyCheckResponded:
+		return event.ActorUserId + " is " + event.NewStatus
+	case models.PresenceEventPartyStatusChanged:
+		return "party " + event.PartyId + " is now " + event.NewStatus
+	}
+	return fmt.Sprintf("%v: %v", event.Type, event.ActorUserId)
+}
@@ -0,0 +1,513 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// lobby state machine of a game party:
+// active (lobby) -> ready-check -> in-match -> active.
+// A not-ready answer sends the party back to the lobby
+type ReadyCheckService interface {
+	ValidateStartRequest(ctx context.Context, requestData *models.StartReadyCheckRequestData) []string
+	StartReadyCheck(ctx context.Context, requestData *models.StartReadyCheckRequestData) (*time.Time, error)
+	ValidateRespondRequest(ctx context.Context, requestData *models.RespondReadyCheckRequestData) []string
+	RespondToReadyCheck(ctx context.Context, requestData *models.RespondReadyCheckRequestData) error
+	ValidateFinishMatchRequest(ctx context.Context, requestData *models.FinishMatchRequestData) []string
+	FinishMatch(ctx context.Context, requestData *models.FinishMatchRequestData) error
+}
+
+var readyCheckServiceStruct ReadyCheckService
+var readyCheckServiceOnce sync.Once
+
+type readyCheckService struct {
+	gameServer        *models.GameServer
+	mongoDAO          mongodao.MongoDAO
+	eventBus          eventbus.EventBus
+	readyCheckTimeout time.Duration
+}
+
+func InitReadyCheckService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, readyChkTimeout time.Duration) ReadyCheckService {
+	readyCheckServiceOnce.Do(func() {
+		readyCheckServiceStruct = &readyCheckService{
+			gameServer:        gameSrvr,
+			mongoDAO:          mongodao,
+			eventBus:          evntBus,
+			readyCheckTimeout: readyChkTimeout,
+		}
+	})
+	return readyCheckServiceStruct
+}
+
+func GetReadyCheckService() ReadyCheckService {
+	if readyCheckServiceStruct == nil {
+		panic("ReadyCheck Service not initialized")
+	}
+	return readyCheckServiceStruct
+}
+
+func (c readyCheckService) ValidateStartRequest(ctx context.Context, requestData *models.StartReadyCheckRequestData) []string {
+	var errs []error
+	var errorString []string
+
+	if requestData.UserId == literals.EmptyString {
+		errs = append(errs, errors.New("empty userId in the request data"))
+	}
+
+	if requestData.PartyId == literals.EmptyString {
+		errs = append(errs, errors.New("empty partyId in the request data"))
+	}
+
+	if errs == nil {
+		c.gameServer.Mutex.Lock()
+		if gameParty, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
+			errs = append(errs, errors.New("invalid partyId in the request data"))
+		} else if common.PartyLeader(gameParty) != requestData.UserId {
+			errs = append(errs, errors.New(requestData.UserId+" is not the leader of party "+requestData.PartyId))
+		} else if gameParty.Status != models.GamePartyStatusActive {
+			errs = append(errs, errors.New("party "+requestData.PartyId+" has status: "+string(gameParty.Status)+". a ready-check can only be started in the lobby"))
+		} else if countPlayers(gameParty, models.PlayerJoinedStatus) == 0 {
+			errs = append(errs, errors.New("no joined players in party "+requestData.PartyId))
+		}
+		c.gameServer.Mutex.Unlock()
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+		return errorString
+	}
+
+	return nil
+}
+
+func (c readyCheckService) ValidateRespondRequest(ctx context.Context, requestData *models.RespondReadyCheckRequestData) []string {
+	var errs []error
+	var errorString []string
+
+	if requestData.UserId == literals.EmptyString {
+		errs = append(errs, errors.New("empty userId in the request data"))
+	}
+
+	if requestData.PartyId == literals.EmptyString {
+		errs = append(errs, errors.New("empty partyId in the request data"))
+	}
+
+	if requestData.Response != models.ReadyCheckResponseReady && requestData.Response != models.ReadyCheckResponseNotReady {
+		errs = append(errs, errors.New("invalid response "+string(requestData.Response)+" in the request data. should be "+string(models.ReadyCheckResponseReady)+" or "+string(models.ReadyCheckResponseNotReady)))
+	}
+
+	if errs == nil {
+		c.gameServer.Mutex.Lock()
+		if gameParty, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
+			errs = append(errs, errors.New("invalid partyId in the request data"))
+		} else if gameParty.Status != models.GamePartyStatusReadyCheck {
+			errs = append(errs, errors.New("party "+requestData.PartyId+" has status: "+string(gameParty.Status)+". no ready-check running"))
+		} else if playerStatus := gameParty.Players[requestData.UserId]; playerStatus != models.PlayerJoinedStatus {
+			// the leader started the ready-check and is ready
+			errs = append(errs, errors.New("only joined players can answer the ready-check. "+requestData.UserId+" has status: "+string(playerStatus)))
+		}
+		c.gameServer.Mutex.Unlock()
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+		return errorString
+	}
+
+	return nil
+}
+
+func (c readyCheckService) ValidateFinishMatchRequest(ctx context.Context, requestData *models.FinishMatchRequestData) []string {
+	var errs []error
+	var errorString []string
+
+	if requestData.UserId == literals.EmptyString {
+		errs = append(errs, errors.New("empty userId in the request data"))
+	}
+
+	if requestData.PartyId == literals.EmptyString {
+		errs = append(errs, errors.New("empty partyId in the request data"))
+	}
+
+	if errs == nil {
+		c.gameServer.Mutex.Lock()
+		if gameParty, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
+			errs = append(errs, errors.New("invalid partyId in the request data"))
+		} else if common.PartyLeader(gameParty) != requestData.UserId {
+			errs = append(errs, errors.New(requestData.UserId+" is not the leader of party "+requestData.PartyId))
+		} else if gameParty.Status != models.GamePartyStatusInMatch {
+			errs = append(errs, errors.New("party "+requestData.PartyId+" has status: "+string(gameParty.Status)+". no match running"))
+		}
+		c.gameServer.Mutex.Unlock()
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+		return errorString
+	}
+
+	return nil
+}
+
+func StartReadyCheckHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var expiresAt *time.Time
+	var err error
+
+	defer func() {
+		result := models.StartReadyCheckResponseData{
+			Success:   success,
+			ExpiresAt: expiresAt,
+			Errors:    errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	requestData := &models.StartReadyCheckRequestData{}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("failed to read start ready-check message: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	err = json.Unmarshal(data, requestData)
+	if err != nil {
+		fmt.Printf("failed to unmarshal start ready-check message : %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
+	fmt.Printf("Request data: %+v\n", requestData)
+
+	svc := GetReadyCheckService()
+
+	errStrings = svc.ValidateStartRequest(ctx, requestData)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	expiresAt, err = svc.StartReadyCheck(ctx, requestData)
+	if err != nil {
+		fmt.Printf("failed to start the ready-check: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+func RespondReadyCheckHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.RespondReadyCheckResponseData{
+			Success: success,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	requestData := &models.RespondReadyCheckRequestData{}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("failed to read ready-check response message: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	err = json.Unmarshal(data, requestData)
+	if err != nil {
+		fmt.Printf("failed to unmarshal ready-check response message : %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
+	fmt.Printf("Request data: %+v\n", requestData)
+
+	svc := GetReadyCheckService()
+
+	errStrings = svc.ValidateRespondRequest(ctx, requestData)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	err = svc.RespondToReadyCheck(ctx, requestData)
+	if err != nil {
+		fmt.Printf("failed to answer the ready-check: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+func FinishMatchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.FinishMatchResponseData{
+			Success: success,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	requestData := &models.FinishMatchRequestData{}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("failed to read finish match message: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	err = json.Unmarshal(data, requestData)
+	if err != nil {
+		fmt.Printf("failed to unmarshal finish match message : %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
+	fmt.Printf("Request data: %+v\n", requestData)
+
+	svc := GetReadyCheckService()
+
+	errStrings = svc.ValidateFinishMatchRequest(ctx, requestData)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	err = svc.FinishMatch(ctx, requestData)
+	if err != nil {
+		fmt.Printf("failed to finish the match: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+// the leader is ready. The match starts once every joined player is ready or the ready-check times out
+func (c readyCheckService) StartReadyCheck(ctx context.Context, requestData *models.StartReadyCheckRequestData) (*time.Time, error) {
+
+	startedAt := time.Now()
+	readyCheck := &models.ReadyCheck{
+		StartedBy: requestData.UserId,
+		StartedAt: startedAt,
+		ExpiresAt: startedAt.Add(c.readyCheckTimeout),
+		Responses: make(map[string]models.ReadyCheckResponse),
+	}
+
+	c.gameServer.Mutex.Lock()
+	gameParty, ok := c.gameServer.Parties[requestData.PartyId]
+	if !ok || gameParty.Status != models.GamePartyStatusActive {
+		c.gameServer.Mutex.Unlock()
+		return nil, errors.New("party " + requestData.PartyId + " is no longer in the lobby")
+	}
+	oldReadyCheck := gameParty.ReadyCheck
+	gameParty.Status = models.GamePartyStatusReadyCheck
+	gameParty.ReadyCheck = readyCheck
+	c.gameServer.Mutex.Unlock()
+
+	err := c.mongoDAO.StartGamePartyReadyCheck(ctx, requestData.PartyId, readyCheck)
+	if err != nil {
+		// back to the lobby, unless the ready-check has already moved on
+		c.gameServer.Mutex.Lock()
+		if gameParty.Status == models.GamePartyStatusReadyCheck && gameParty.ReadyCheck == readyCheck {
+			gameParty.Status = models.GamePartyStatusActive
+			gameParty.ReadyCheck = oldReadyCheck
+		}
+		c.gameServer.Mutex.Unlock()
+		return nil, err
+	}
+
+	c.eventBus.Publish(eventbus.PartyTopic(requestData.PartyId), &models.PresenceEvent{
+		Type:        models.PresenceEventReadyCheckStarted,
+		ActorUserId: requestData.UserId,
+		PartyId:     requestData.PartyId,
+		OldStatus:   string(models.GamePartyStatusActive),
+		NewStatus:   string(models.GamePartyStatusReadyCheck),
+		Timestamp:   startedAt,
+	})
+
+	return &readyCheck.ExpiresAt, nil
+}
+
+func (c readyCheckService) RespondToReadyCheck(ctx context.Context, requestData *models.RespondReadyCheckRequestData) error {
+
+	c.gameServer.Mutex.Lock()
+	gameParty, ok := c.gameServer.Parties[requestData.PartyId]
+	if !ok || gameParty.Status != models.GamePartyStatusReadyCheck || gameParty.ReadyCheck == nil {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("ready-check of party " + requestData.PartyId + " is over")
+	}
+	oldResponse := gameParty.ReadyCheck.Responses[requestData.UserId]
+	if gameParty.ReadyCheck.Responses == nil {
+		gameParty.ReadyCheck.Responses = make(map[string]models.ReadyCheckResponse)
+	}
+	gameParty.ReadyCheck.Responses[requestData.UserId] = requestData.Response
+	complete := readyCheckComplete(gameParty)
+	c.gameServer.Mutex.Unlock()
+
+	err := c.mongoDAO.UpdateReadyCheckResponse(ctx, requestData.PartyId, requestData.UserId, requestData.Response)
+	if err != nil {
+		return err
+	}
+
+	c.eventBus.Publish(eventbus.PartyTopic(requestData.PartyId), &models.PresenceEvent{
+		Type:        models.PresenceEventReadyCheckResponded,
+		ActorUserId: requestData.UserId,
+		PartyId:     requestData.PartyId,
+		OldStatus:   string(oldResponse),
+		NewStatus:   string(requestData.Response),
+		Timestamp:   time.Now(),
+	})
+
+	if requestData.Response == models.ReadyCheckResponseNotReady {
+		return changeGamePartyStatus(ctx, c.gameServer, c.mongoDAO, c.eventBus, requestData.PartyId, requestData.UserId, models.GamePartyStatusReadyCheck, models.GamePartyStatusActive)
+	}
+	if complete {
+		return changeGamePartyStatus(ctx, c.gameServer, c.mongoDAO, c.eventBus, requestData.PartyId, literals.EmptyString, models.GamePartyStatusReadyCheck, models.GamePartyStatusInMatch)
+	}
+	return nil
+}
+
+// back to the lobby
+func (c readyCheckService) FinishMatch(ctx context.Context, requestData *models.FinishMatchRequestData) error {
+	return changeGamePartyStatus(ctx, c.gameServer, c.mongoDAO, c.eventBus, requestData.PartyId, requestData.UserId, models.GamePartyStatusInMatch, models.GamePartyStatusActive)
+}
+
+// start the match of every party whose joined players are all ready or whose ready-check timed out.
+// Players can exit during the ready-check, so completion is checked here too. Called periodically
+func CompleteReadyChecks(ctx context.Context, gameServer *models.GameServer, mongoDAO mongodao.MongoDAO, eventBus eventbus.EventBus) error {
+
+	now := time.Now()
+	var partyIds []string
+
+	gameServer.Mutex.Lock()
+	for partyId, gameParty := range gameServer.Parties {
+		if gameParty.Status != models.GamePartyStatusReadyCheck {
+			continue
+		}
+		if gameParty.ReadyCheck == nil || !now.Before(gameParty.ReadyCheck.ExpiresAt) || readyCheckComplete(gameParty) {
+			partyIds = append(partyIds, partyId)
+		}
+	}
+	gameServer.Mutex.Unlock()
+
+	for _, partyId := range partyIds {
+		err := changeGamePartyStatus(ctx, gameServer, mongoDAO, eventBus, partyId, literals.EmptyString, models.GamePartyStatusReadyCheck, models.GamePartyStatusInMatch)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// every joined player answered ready. gameServer.Mutex has to be held
+func readyCheckComplete(gameParty *models.GameParty) bool {
+	for playerId, playerStatus := range gameParty.Players {
+		if playerStatus == models.PlayerJoinedStatus && gameParty.ReadyCheck.Responses[playerId] != models.ReadyCheckResponseReady {
+			return false
+		}
+	}
+	return true
+}
+
+// move the party from one status to the other and let the party know.
+// Does nothing if the party has left fromStatus in the meantime.
+// actorId is empty when the server moves the party
+func changeGamePartyStatus(ctx context.Context, gameServer *models.GameServer, mongoDAO mongodao.MongoDAO, eventBus eventbus.EventBus, partyId string, actorId string, fromStatus models.GamePartyStatus, toStatus models.GamePartyStatus) error {
+
+	gameServer.Mutex.Lock()
+	gameParty, ok := gameServer.Parties[partyId]
+	if !ok || gameParty.Status != fromStatus {
+		gameServer.Mutex.Unlock()
+		return nil
+	}
+	gameParty.Status = toStatus
+	gameServer.Mutex.Unlock()
+
+	err := mongoDAO.UpdateGamePartyStatus(ctx, []string{partyId}, toStatus)
+	if err != nil {
+		// memory has to keep matching the stored status
+		gameServer.Mutex.Lock()
+		if gameParty.Status == toStatus {
+			gameParty.Status = fromStatus
+		}
+		gameServer.Mutex.Unlock()
+		return err
+	}
+
+	eventBus.Publish(eventbus.PartyTopic(partyId), &models.PresenceEvent{
+		Type:        models.PresenceEventPartyStatusChanged,
+		ActorUserId: actorId,
+		PartyId:     partyId,
+		OldStatus:   string(fromStatus),
+		NewStatus:   string(toStatus),
+		Timestamp:   time.Now(),
+	})
+	return nil
+}
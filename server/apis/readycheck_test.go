@@ -0,0 +1,160 @@
+package apis
+
+import (
+	"context"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/eventbus"
+	"testing"
+	"time"
+)
+
+func TestReadyCheckTransitions(t *testing.T) {
+	tests := []struct {
+		name       string
+		responses  []*models.RespondReadyCheckRequestData
+		timedOut   bool
+		wantStatus models.GamePartyStatus
+	}{
+		{
+			name:       "waits for every joined player",
+			responses:  []*models.RespondReadyCheckRequestData{{UserId: "p1", Response: models.ReadyCheckResponseReady}},
+			wantStatus: models.GamePartyStatusReadyCheck,
+		},
+		{
+			name: "every joined player ready starts the match",
+			responses: []*models.RespondReadyCheckRequestData{
+				{UserId: "p1", Response: models.ReadyCheckResponseReady},
+				{UserId: "p2", Response: models.ReadyCheckResponseReady},
+			},
+			wantStatus: models.GamePartyStatusInMatch,
+		},
+		{
+			name:       "one player not ready goes back to the lobby",
+			responses:  []*models.RespondReadyCheckRequestData{{UserId: "p1", Response: models.ReadyCheckResponseNotReady}},
+			wantStatus: models.GamePartyStatusActive,
+		},
+		{
+			name:       "timed out ready-check starts the match",
+			responses:  []*models.RespondReadyCheckRequestData{{UserId: "p1", Response: models.ReadyCheckResponseReady}},
+			timedOut:   true,
+			wantStatus: models.GamePartyStatusInMatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mongoDAO := mongodao.NewInMemoryDao()
+			createTestUsers(t, mongoDAO, "leader", "p1", "p2")
+			gameServer := newTestGameServer(t, mongoDAO)
+			storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Players: map[string]models.GamePartyPlayerStatus{
+				"p1": models.PlayerJoinedStatus,
+				"p2": models.PlayerJoinedStatus,
+			}})
+
+			readyCheckTimeout := time.Hour
+			if tt.timedOut {
+				readyCheckTimeout = 0
+			}
+			eventBus := eventbus.NewEventBus()
+			svc := readyCheckService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventBus, readyCheckTimeout: readyCheckTimeout}
+
+			startData := &models.StartReadyCheckRequestData{PartyId: "party1", UserId: "leader"}
+			if errs := svc.ValidateStartRequest(ctx, startData); errs != nil {
+				t.Fatalf("ValidateStartRequest: %v", errs)
+			}
+			if _, err := svc.StartReadyCheck(ctx, startData); err != nil {
+				t.Fatalf("StartReadyCheck: %v", err)
+			}
+			for _, respondData := range tt.responses {
+				respondData.PartyId = "party1"
+				if errs := svc.ValidateRespondRequest(ctx, respondData); errs != nil {
+					t.Fatalf("ValidateRespondRequest: %v", errs)
+				}
+				if err := svc.RespondToReadyCheck(ctx, respondData); err != nil {
+					t.Fatalf("RespondToReadyCheck: %v", err)
+				}
+			}
+			if err := CompleteReadyChecks(ctx, gameServer, mongoDAO, eventBus); err != nil {
+				t.Fatalf("CompleteReadyChecks: %v", err)
+			}
+
+			if partyStatus := storedPartyStatus(t, gameServer, mongoDAO, "party1"); partyStatus != tt.wantStatus {
+				t.Errorf("party status = %v, want %v", partyStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestFinishMatch(t *testing.T) {
+	ctx := context.TODO()
+	mongoDAO := mongodao.NewInMemoryDao()
+	createTestUsers(t, mongoDAO, "leader", "p1")
+	gameServer := newTestGameServer(t, mongoDAO)
+	storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Status: models.GamePartyStatusInMatch, Players: map[string]models.GamePartyPlayerStatus{"p1": models.PlayerJoinedStatus}})
+	svc := readyCheckService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus(), readyCheckTimeout: time.Hour}
+
+	if errs := svc.ValidateFinishMatchRequest(ctx, &models.FinishMatchRequestData{PartyId: "party1", UserId: "p1"}); errs == nil {
+		t.Errorf("ValidateFinishMatchRequest let a player who is not the leader finish the match")
+	}
+
+	finishData := &models.FinishMatchRequestData{PartyId: "party1", UserId: "leader"}
+	if errs := svc.ValidateFinishMatchRequest(ctx, finishData); errs != nil {
+		t.Fatalf("ValidateFinishMatchRequest: %v", errs)
+	}
+	if err := svc.FinishMatch(ctx, finishData); err != nil {
+		t.Fatalf("FinishMatch: %v", err)
+	}
+	if partyStatus := storedPartyStatus(t, gameServer, mongoDAO, "party1"); partyStatus != models.GamePartyStatusActive {
+		t.Errorf("party status = %v, want %v", partyStatus, models.GamePartyStatusActive)
+	}
+
+	// no match running anymore
+	if errs := svc.ValidateFinishMatchRequest(ctx, finishData); errs == nil {
+		t.Errorf("ValidateFinishMatchRequest let the leader finish a match twice")
+	}
+}
+
+func TestInvitesAndJoinsOnlyInTheLobby(t *testing.T) {
+	tests := []struct {
+		name        string
+		partyStatus models.GamePartyStatus
+		wantAllowed bool
+	}{
+		{name: "lobby", partyStatus: models.GamePartyStatusActive, wantAllowed: true},
+		{name: "ready-check", partyStatus: models.GamePartyStatusReadyCheck},
+		{name: "in match", partyStatus: models.GamePartyStatusInMatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mongoDAO := mongodao.NewInMemoryDao()
+			createTestUsers(t, mongoDAO, "leader", "u1", "u2")
+			befriend(t, mongoDAO, "leader", "u1")
+			gameServer := newTestGameServer(t, mongoDAO)
+			storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Status: tt.partyStatus, Players: map[string]models.GamePartyPlayerStatus{"u2": models.PlayerAcceptedStatus}})
+			eventBus := eventbus.NewEventBus()
+
+			inviteSvc := inviteToGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventBus, maxPartySize: 8}
+			inviteData := &models.InviteToGamePartyRequestData{PartyId: "party1", UserId: "leader", FriendIds: []string{"u1"}}
+			if errs := inviteSvc.ValidateRequest(ctx, inviteData); (errs == nil) != tt.wantAllowed {
+				t.Errorf("invite ValidateRequest = %v, want allowed %v", errs, tt.wantAllowed)
+			}
+			// the party may have left the lobby after the invitation was validated
+			if err := inviteSvc.StoreInvitationToGameParty(ctx, inviteData); (err == nil) != tt.wantAllowed {
+				t.Errorf("StoreInvitationToGameParty = %v, want allowed %v", err, tt.wantAllowed)
+			}
+
+			joinSvc := joinGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventBus, maxPartySize: 8}
+			joinData := &models.JoinGamePartyRequestData{PartyId: "party1", UserId: "u2"}
+			if errs := joinSvc.ValidateRequest(ctx, joinData); (errs == nil) != tt.wantAllowed {
+				t.Errorf("join ValidateRequest = %v, want allowed %v", errs, tt.wantAllowed)
+			}
+			if err := joinSvc.JoinGameParty(ctx, joinData); (err == nil) != tt.wantAllowed {
+				t.Errorf("JoinGameParty = %v, want allowed %v", err, tt.wantAllowed)
+			}
+		})
+	}
+}
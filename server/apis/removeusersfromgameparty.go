@@ -9,8 +9,12 @@ import (
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
 	"net/http"
 	"sync"
+	"time"
 )
 
 type RemoveUsersFromGamePartyService interface {
@@ -24,13 +28,15 @@ var removeUsersFromGamePartyServiceOnce sync.Once
 type removeUsersFromGamePartyService struct {
 	gameServer *models.GameServer
 	mongoDAO   mongodao.MongoDAO
+	eventBus   eventbus.EventBus
 }
 
-func InitRemoveUsersFromGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO) RemoveUsersFromGamePartyService {
+func InitRemoveUsersFromGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus) RemoveUsersFromGamePartyService {
 	removeUsersFromGamePartyServiceOnce.Do(func() {
 		removeUsersFromGamePartyServiceStruct = &removeUsersFromGamePartyService{
 			gameServer: gameSrvr,
 			mongoDAO:   mongodao,
+			eventBus:   evntBus,
 		}
 	})
 	return removeUsersFromGamePartyServiceStruct
@@ -47,10 +53,12 @@ func (c removeUsersFromGamePartyService) ValidateRequest(ctx context.Context, re
 	var errs []error
 	var errorString []string
 
+	// the parties are also updated by the background workers
+	c.gameServer.Mutex.Lock()
 	if requestData.UserId == literals.EmptyString {
 		errs = append(errs, errors.New("empty userId in the request data"))
-	} else if c.gameServer.Parties[requestData.PartyId] != nil && requestData.UserId != c.gameServer.Parties[requestData.PartyId].CreatedBy {
-		errs = append(errs, errors.New("party "+requestData.PartyId+" not created by "+requestData.UserId))
+	} else if c.gameServer.Parties[requestData.PartyId] != nil && requestData.UserId != common.PartyLeader(c.gameServer.Parties[requestData.PartyId]) {
+		errs = append(errs, errors.New(requestData.UserId+" is not the leader of party "+requestData.PartyId))
 	}
 
 	// friend Ids should not be empty
@@ -84,27 +92,24 @@ func (c removeUsersFromGamePartyService) ValidateRequest(ctx context.Context, re
 
 	// check party data only if userId and friendIds are correct
 	if errs == nil {
-		if requestData.PartyId == literals.EmptyString {
-			errs = append(errs, errors.New("empty partyId in the request data"))
+		if gameParty, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
+			errs = append(errs, errors.New("invalid partyId in the request data"))
 		} else {
-			if _, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
-				errs = append(errs, errors.New("invalid partyId in the request data"))
-			} else if c.gameServer.Parties[requestData.PartyId].Players != nil {
-				// players present in the game party
-				for _, playerId := range requestData.FriendIds {
-					if playerStatus, ok := c.gameServer.Parties[requestData.PartyId].Players[playerId]; ok {
-						// playerId already present
-						// can be removed if player status is 'joined'
-						if playerStatus != models.PlayerJoinedStatus {
-							// cannot remove this player
-							errs = append(errs, errors.New("player "+playerId+" cannot be removed. Has status: "+string(playerStatus)))
-						}
-					}
+			// only players who have joined can be removed. The leader leaves by exiting
+			for _, playerId := range requestData.FriendIds {
+				if playerId == common.PartyLeader(gameParty) {
+					errs = append(errs, errors.New("leader "+playerId+" cannot be removed from party "+requestData.PartyId))
+				} else if playerStatus, ok := gameParty.Players[playerId]; !ok {
+					errs = append(errs, errors.New("player "+playerId+" not found in the game party"))
+				} else if playerStatus != models.PlayerJoinedStatus {
+					errs = append(errs, errors.New("player "+playerId+" cannot be removed. Has status: "+string(playerStatus)))
 				}
 			}
 		}
 	}
 
+	c.gameServer.Mutex.Unlock()
+
 	if len(errs) > 0 {
 		for _, err := range errs {
 			errorString = append(errorString, err.Error())
@@ -151,6 +156,9 @@ func RemoveFromGamePartyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
 	fmt.Printf("Request data: %+v\n", requestData)
 
 	svc := GetRemoveUsersFromGamePartyService()
@@ -174,22 +182,68 @@ func RemoveFromGamePartyHandler(w http.ResponseWriter, r *http.Request) {
 
 func (c removeUsersFromGamePartyService) RemoveUsersFromGameParty(ctx context.Context, requestData *models.RemoveUsersFromGamePartyRequestData) error {
 
-	err := c.mongoDAO.UpdatePlayersDecisionForGameParty(ctx, requestData.PartyId, requestData.FriendIds, models.PlayerRemovedStatus)
+	// only players who are still joined are removed, the others have exited or been removed in the meantime.
+	// They leave the party in memory first and are put back if the removal cannot be stored.
+	// The status before the removal is sent with the event
+	var leftPlayerIds []string
+	oldPlayersStatus := make(map[string]models.GamePartyPlayerStatus)
+	c.gameServer.Mutex.Lock()
+	gameParty, ok := c.gameServer.Parties[requestData.PartyId]
+	if !ok {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("game party is over")
+	}
+	for _, playerId := range requestData.FriendIds {
+		if playerStatus := gameParty.Players[playerId]; playerStatus == models.PlayerJoinedStatus {
+			leftPlayerIds = append(leftPlayerIds, playerId)
+			oldPlayersStatus[playerId] = playerStatus
+			gameParty.Players[playerId] = models.PlayerRemovedStatus
+			common.LeaveParty(c.gameServer, playerId, requestData.PartyId)
+		}
+	}
+	c.gameServer.Mutex.Unlock()
+
+	if len(leftPlayerIds) == 0 {
+		return nil
+	}
+
+	err := c.mongoDAO.UpdatePlayersDecisionForGameParty(ctx, requestData.PartyId, leftPlayerIds, models.PlayerRemovedStatus)
 	if err != nil {
+		c.gameServer.Mutex.Lock()
+		// nothing to put back once the party has ended. Players who joined another party in the meantime are left alone
+		if _, active := c.gameServer.Parties[requestData.PartyId]; active {
+			for _, playerId := range leftPlayerIds {
+				if _, inParty := common.CurrentParty(c.gameServer, playerId); gameParty.Players[playerId] == models.PlayerRemovedStatus && !inParty {
+					gameParty.Players[playerId] = oldPlayersStatus[playerId]
+					c.gameServer.UserParties[playerId] = requestData.PartyId
+				}
+			}
+		}
+		c.gameServer.Mutex.Unlock()
 		return err
 	}
 
 	// update the users status to idle
-	_, err = c.mongoDAO.UpdateUsersStatus(ctx, requestData.FriendIds, models.UserStatusIdle)
+	err = UpdateUsersStatusFromMembership(ctx, c.gameServer, c.mongoDAO, c.eventBus, leftPlayerIds)
 	if err != nil {
 		return err
 	}
 
-	c.gameServer.Mutex.Lock()
-	for _, playerId := range requestData.FriendIds {
-		c.gameServer.Parties[requestData.PartyId].Players[playerId] = models.PlayerRemovedStatus
+	for _, playerId := range leftPlayerIds {
+		c.eventBus.Publish(eventbus.PartyTopic(requestData.PartyId), &models.PresenceEvent{
+			Type:         models.PresenceEventPlayerRemoved,
+			ActorUserId:  requestData.UserId,
+			TargetUserId: playerId,
+			PartyId:      requestData.PartyId,
+			OldStatus:    string(oldPlayersStatus[playerId]),
+			NewStatus:    string(models.PlayerRemovedStatus),
+			Timestamp:    time.Now(),
+		})
+
+		// the removed player is no longer part of the party, end its party streams
+		c.eventBus.UnsubscribeSubscriber(eventbus.PartyTopic(requestData.PartyId), playerId)
+		c.eventBus.UnsubscribeSubscriber(eventbus.ChatTopic(requestData.PartyId), playerId)
 	}
-	c.gameServer.Mutex.Unlock()
 
 	return nil
 }
@@ -0,0 +1,107 @@
+package apis
+
+import (
+	"context"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/eventbus"
+	"testing"
+)
+
+func TestRemoveUsersFromGameParty(t *testing.T) {
+	tests := []struct {
+		name        string
+		friendId    string
+		wantRemoved bool
+	}{
+		{name: "joined player is removed", friendId: "p1", wantRemoved: true},
+		{name: "leader cannot remove themselves", friendId: "leader"},
+		{name: "invited player cannot be removed", friendId: "p2"},
+		{name: "user outside the party cannot be removed", friendId: "u1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			mongoDAO := mongodao.NewInMemoryDao()
+			createTestUsers(t, mongoDAO, "leader", "p1", "p2", "u1")
+			gameServer := newTestGameServer(t, mongoDAO)
+			storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Players: map[string]models.GamePartyPlayerStatus{
+				"p1": models.PlayerJoinedStatus,
+				"p2": models.PlayerInvitedStatus,
+			}})
+			playersBefore := map[string]models.GamePartyPlayerStatus{"p1": models.PlayerJoinedStatus, "p2": models.PlayerInvitedStatus}
+
+			svc := removeUsersFromGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventbus.NewEventBus()}
+			requestData := &models.RemoveUsersFromGamePartyRequestData{PartyId: "party1", UserId: "leader", FriendIds: []string{tt.friendId}}
+			errs := svc.ValidateRequest(ctx, requestData)
+			if (errs == nil) != tt.wantRemoved {
+				t.Fatalf("ValidateRequest = %v, want removed %v", errs, tt.wantRemoved)
+			}
+			if errs == nil {
+				if err := svc.RemoveUsersFromGameParty(ctx, requestData); err != nil {
+					t.Fatalf("RemoveUsersFromGameParty: %v", err)
+				}
+				playersBefore[tt.friendId] = models.PlayerRemovedStatus
+			}
+
+			for playerId, wantStatus := range playersBefore {
+				if playerStatus := storedPlayerStatus(t, gameServer, mongoDAO, "party1", playerId); playerStatus != wantStatus {
+					t.Errorf("status of %v = %v, want %v", playerId, playerStatus, wantStatus)
+				}
+			}
+			gameServer.Mutex.Lock()
+			leaderPartyId := gameServer.UserParties["leader"]
+			_, removedInParty := gameServer.UserParties["p1"]
+			gameServer.Mutex.Unlock()
+			if leaderPartyId != "party1" {
+				t.Errorf("leader is in party %q, want party1", leaderPartyId)
+			}
+			if removedInParty == tt.wantRemoved {
+				t.Errorf("p1 in a party = %v, want %v", removedInParty, !tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestRemoveUsersFromGamePartySkipsPlayersWhoLeft(t *testing.T) {
+	ctx := context.TODO()
+	mongoDAO := mongodao.NewInMemoryDao()
+	createTestUsers(t, mongoDAO, "leader", "p1", "p2")
+	gameServer := newTestGameServer(t, mongoDAO)
+	storeTestParty(t, gameServer, mongoDAO, &models.GameParty{PartyId: "party1", CreatedBy: "leader", Players: map[string]models.GamePartyPlayerStatus{
+		"p1": models.PlayerJoinedStatus,
+		"p2": models.PlayerJoinedStatus,
+	}})
+	eventBus := eventbus.NewEventBus()
+	subscription := eventBus.Subscribe(eventbus.PartyTopic("party1"), "leader", "leader-session")
+	svc := removeUsersFromGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventBus}
+
+	requestData := &models.RemoveUsersFromGamePartyRequestData{PartyId: "party1", UserId: "leader", FriendIds: []string{"p1", "p2"}}
+	if errs := svc.ValidateRequest(ctx, requestData); errs != nil {
+		t.Fatalf("ValidateRequest: %v", errs)
+	}
+	// p2 exits after the request was validated
+	exitGamePartySvc := exitGamePartyService{gameServer: gameServer, mongoDAO: mongoDAO, eventBus: eventBus}
+	if err := exitGamePartySvc.ExitGameParty(ctx, &models.ExitGamePartyRequestData{PartyId: "party1", UserId: "p2"}); err != nil {
+		t.Fatalf("ExitGameParty: %v", err)
+	}
+	<-subscription.Events
+
+	if err := svc.RemoveUsersFromGameParty(ctx, requestData); err != nil {
+		t.Fatalf("RemoveUsersFromGameParty: %v", err)
+	}
+
+	if playerStatus := storedPlayerStatus(t, gameServer, mongoDAO, "party1", "p2"); playerStatus != models.PlayerExitedStatus {
+		t.Errorf("status of p2 = %v, want %v", playerStatus, models.PlayerExitedStatus)
+	}
+	event, ok := (<-subscription.Events).(*models.PresenceEvent)
+	if !ok || event.Type != models.PresenceEventPlayerRemoved || event.TargetUserId != "p1" || event.OldStatus != string(models.PlayerJoinedStatus) {
+		t.Errorf("first event after the removal = %+v, want p1 removed", event)
+	}
+	select {
+	case event := <-subscription.Events:
+		t.Errorf("unexpected event %+v", event)
+	default:
+	}
+}
@@ -0,0 +1,200 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type RequestJoinGamePartyService interface {
+	ValidateRequest(ctx context.Context, requestData *models.RequestJoinGamePartyRequestData) []string
+	RequestJoinGameParty(ctx context.Context, requestData *models.RequestJoinGamePartyRequestData) error
+}
+
+var requestJoinGamePartyServiceStruct RequestJoinGamePartyService
+var requestJoinGamePartyServiceOnce sync.Once
+
+type requestJoinGamePartyService struct {
+	gameServer   *models.GameServer
+	mongoDAO     mongodao.MongoDAO
+	eventBus     eventbus.EventBus
+	maxPartySize int
+}
+
+func InitRequestJoinGamePartyService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, maxPartySz int) RequestJoinGamePartyService {
+	requestJoinGamePartyServiceOnce.Do(func() {
+		requestJoinGamePartyServiceStruct = &requestJoinGamePartyService{
+			gameServer:   gameSrvr,
+			mongoDAO:     mongodao,
+			eventBus:     evntBus,
+			maxPartySize: maxPartySz,
+		}
+	})
+	return requestJoinGamePartyServiceStruct
+}
+
+func GetRequestJoinGamePartyService() RequestJoinGamePartyService {
+	if requestJoinGamePartyServiceStruct == nil {
+		panic("RequestJoinGameParty Service not initialized")
+	}
+	return requestJoinGamePartyServiceStruct
+}
+
+func (c requestJoinGamePartyService) ValidateRequest(ctx context.Context, requestData *models.RequestJoinGamePartyRequestData) []string {
+	var errs []error
+	var errorString []string
+
+	if requestData.UserId == literals.EmptyString {
+		errs = append(errs, errors.New("empty userId in the request data"))
+	}
+
+	if requestData.PartyId == literals.EmptyString {
+		errs = append(errs, errors.New("empty partyId in the request data"))
+	}
+
+	var leaderId string
+	if errs == nil {
+		c.gameServer.Mutex.Lock()
+		if gameParty, ok := c.gameServer.Parties[requestData.PartyId]; !ok {
+			errs = append(errs, errors.New("invalid partyId in the request data"))
+		} else {
+			leaderId = common.PartyLeader(gameParty)
+			playerStatus := gameParty.Players[requestData.UserId]
+
+			if leaderId == requestData.UserId {
+				errs = append(errs, errors.New("user "+requestData.UserId+" is the leader of the party"))
+			} else if playerStatus == models.PlayerInvitedStatus || playerStatus == models.PlayerAcceptedStatus || playerStatus == models.PlayerJoinedStatus {
+				errs = append(errs, errors.New("player "+requestData.UserId+" has current status: "+string(playerStatus)+". no invitation needed"))
+			} else if _, ok := gameParty.JoinRequests[requestData.UserId]; ok {
+				errs = append(errs, errors.New("user "+requestData.UserId+" has already asked to join party "+requestData.PartyId))
+			} else {
+				// the invitation would need a free slot
+				capacity := partyCapacity(gameParty, c.maxPartySize)
+				if slotsLeft := capacity - 1 - countPlayers(gameParty, models.PlayerInvitedStatus, models.PlayerAcceptedStatus, models.PlayerJoinedStatus); slotsLeft <= 0 {
+					errs = append(errs, errors.New("party "+requestData.PartyId+" is full. capacity: "+fmt.Sprint(capacity)+" players, 0 slots left"))
+				}
+			}
+		}
+		c.gameServer.Mutex.Unlock()
+	}
+
+	// only friends can be invited by the leader
+	if errs == nil {
+		if areFriends, err := c.mongoDAO.CheckFriendship(ctx, leaderId, []string{requestData.UserId}); err != nil || !areFriends {
+			errs = append(errs, errors.New("user "+requestData.UserId+" is not a friend of the party leader "+leaderId))
+		}
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+		return errorString
+	}
+
+	return nil
+}
+
+func RequestJoinGamePartyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.TODO()
+
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.RequestJoinGamePartyResponseData{
+			Success: success,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	requestData := &models.RequestJoinGamePartyRequestData{}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("failed to read request to join game party message: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	err = json.Unmarshal(data, requestData)
+	if err != nil {
+		fmt.Printf("failed to unmarshal request to join game party message : %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	requestData.UserId, _ = auth.UserIdFromContext(r.Context())
+
+	fmt.Printf("Request data: %+v\n", requestData)
+
+	svc := GetRequestJoinGamePartyService()
+
+	errStrings = svc.ValidateRequest(ctx, requestData)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	err = svc.RequestJoinGameParty(ctx, requestData)
+	if err != nil {
+		fmt.Printf("failed to request to join the game party: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+// the request is kept on the party until the leader invites the user, and the party is told about it
+func (c requestJoinGamePartyService) RequestJoinGameParty(ctx context.Context, requestData *models.RequestJoinGamePartyRequestData) error {
+
+	requestedAt := time.Now()
+	err := c.mongoDAO.AddJoinRequestToGameParty(ctx, requestData.PartyId, requestData.UserId, requestedAt)
+	if err != nil {
+		return err
+	}
+
+	c.gameServer.Mutex.Lock()
+	gameParty, ok := c.gameServer.Parties[requestData.PartyId]
+	if !ok {
+		c.gameServer.Mutex.Unlock()
+		return errors.New("game party is over")
+	}
+	if gameParty.JoinRequests == nil {
+		gameParty.JoinRequests = make(map[string]time.Time)
+	}
+	gameParty.JoinRequests[requestData.UserId] = requestedAt
+	leaderId := common.PartyLeader(gameParty)
+	c.gameServer.Mutex.Unlock()
+
+	c.eventBus.Publish(eventbus.PartyTopic(requestData.PartyId), &models.PresenceEvent{
+		Type:         models.PresenceEventPartyJoinRequested,
+		ActorUserId:  requestData.UserId,
+		TargetUserId: leaderId,
+		PartyId:      requestData.PartyId,
+		Timestamp:    requestedAt,
+	})
+
+	return nil
+}
@@ -1,12 +1,17 @@
 package apis
 
 import (
+	"context"
+	"errors"
+	"io"
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/protos/gampepb"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
 	"log"
 	"sync"
-	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -17,17 +22,20 @@ var userServiceOnce sync.Once
 
 type userService struct {
 	gampepb.UnimplementedUserServiceServer
-	userServer *models.UserServer
+	eventBus   eventbus.EventBus
 	gameServer *models.GameServer
 }
 
-func InitStreamService(usrSrvr *models.UserServer, gamerSrvr *models.GameServer) *grpc.Server {
-	// create a gRPC server
-	grpcServer := grpc.NewServer()
+func InitStreamService(evntBus eventbus.EventBus, gamerSrvr *models.GameServer) *grpc.Server {
+	// create a gRPC server. Every call has to carry a valid session token
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(auth.UnaryServerInterceptor),
+		grpc.StreamInterceptor(auth.StreamServerInterceptor),
+	)
 
 	userServiceOnce.Do(func() {
 		userServiceStruct := &userService{
-			userServer: usrSrvr,
+			eventBus:   evntBus,
 			gameServer: gamerSrvr,
 		}
 		gampepb.RegisterUserServiceServer(grpcServer, userServiceStruct)
@@ -36,90 +44,78 @@ func InitStreamService(usrSrvr *models.UserServer, gamerSrvr *models.GameServer)
 	return grpcServer
 }
 
-func (s userService) StreamUserStatusChange(requestData *gampepb.UserStatusChangeRequest, stream gampepb.UserService_StreamUserStatusChangeServer) error {
-
-	log.Printf("stream friend online status update for userId : %v", requestData.UserId)
-
-	var errMsg string
-
-	if requestData.UserId != literals.EmptyString {
-		var wg sync.WaitGroup
-
-		// initialize the channel
-		// this channel should be closed when this user logs out
-		s.userServer.UserDetails[requestData.UserId] = &models.UserDetails{
-			FriendOnlineUpdateMsg: make(chan string),
+// send every event of the subscription until the subscription is closed or the client goes away
+func streamEvents(ctx context.Context, subscription *eventbus.Subscription, send func(event models.Event) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// topic closed. e.g. user logged out
+				return nil
+			}
+			if err := send(event); err != nil {
+				log.Printf("send error %v\n", err)
+				return err
+			}
+			log.Printf("finishing sending the message : %v\n", eventLogMessage(event))
 		}
-		for msg := range s.userServer.UserDetails[requestData.UserId].FriendOnlineUpdateMsg {
-			wg.Add(1)
-			go func(msg string) {
-				defer wg.Done()
-				time.Sleep(1 * time.Second)
-				resp := gampepb.UserStatusChangeResponse{
-					Message: msg,
-				}
+	}
+}
 
-				if err := stream.Send(&resp); err != nil {
-					log.Printf("send error %v\n", err)
-				}
-				log.Printf("finishing sending the message : %v\n", msg)
-			}(msg)
+// send only the presence events. The user, party and invitation topics never carry any other event
+func presenceEventsOnly(send func(event *models.PresenceEvent) error) func(event models.Event) error {
+	return func(event models.Event) error {
+		presenceEvent, ok := event.(*models.PresenceEvent)
+		if !ok {
+			return nil
 		}
-		wg.Wait()
-	} else {
-		errMsg = "empty userId"
+		return send(presenceEvent)
 	}
+}
 
-	if errMsg != literals.EmptyString {
+func (s userService) StreamUserStatusChange(requestData *gampepb.UserStatusChangeRequest, stream gampepb.UserService_StreamUserStatusChangeServer) error {
+
+	// stream is always opened for the authenticated caller
+	requestData.UserId, _ = auth.UserIdFromContext(stream.Context())
+
+	log.Printf("stream friend online status update for userId : %v", requestData.UserId)
+
+	if requestData.UserId == literals.EmptyString {
+		errMsg := "empty userId"
 		log.Println(errMsg)
 		return status.Errorf(codes.InvalidArgument, errMsg)
 	}
-	return nil
+
+	// every device of the user gets its own subscription.
+	// subscription ends when the user logs out or the client closes the stream
+	subscription := s.eventBus.Subscribe(eventbus.UserTopic(requestData.UserId), requestData.UserId, sessionToken(stream.Context()))
+	defer s.eventBus.Unsubscribe(subscription)
+
+	return streamEvents(stream.Context(), subscription, presenceEventsOnly(func(event *models.PresenceEvent) error {
+		return stream.Send(&gampepb.UserStatusChangeResponse{
+			Message: presenceEventMessage(event),
+			Event:   toPresenceEventProto(event),
+		})
+	}))
 }
 
-// will stream the message to the userId whenever a player joins the game
+// will stream the message to the userId whenever a player joins, exits or is removed from the game
 // userId can be of the one who created the party or who has joined the game
 func (s userService) StreamPlayerJoinedStatus(requestData *gampepb.PlayerInPartyRequest, stream gampepb.UserService_StreamPlayerJoinedStatusServer) error {
 
+	// stream is always opened for the authenticated caller
+	requestData.UserId, _ = auth.UserIdFromContext(stream.Context())
+
 	log.Printf("stream player joined message for userId : %v", requestData.PartyId)
 	var errMsg string
 
+	s.gameServer.Mutex.Lock()
 	if s.gameServer.Parties != nil {
 		if gameParty, ok := s.gameServer.Parties[requestData.PartyId]; ok {
-			userFound := false
-
-			for player, playerStatus := range gameParty.Players {
-				if player == requestData.UserId && playerStatus == models.PlayerJoinedStatus {
-					userFound = true
-					break
-				}
-			}
-			if userFound || gameParty.CreatedBy == requestData.UserId {
-
-				var wg sync.WaitGroup
-
-				// initialize the channel to listen to any player joining
-				// this channel should be closed when the player ends the game party // end party API is not yet implemented
-				// gameParty.PlayerStatusUpdateMsg = make(chan string) // unbuffered channel
-				gameParty.PlayerStatusUpdateMsg = make(chan string, 1) // channel with capacity 1 so that sender does not get blocked when sending data for 1 user and can proceed with its work
-
-				for msg := range gameParty.PlayerStatusUpdateMsg {
-					wg.Add(1)
-					go func(msg string) {
-						defer wg.Done()
-						time.Sleep(1 * time.Second)
-						resp := &gampepb.PlayersInPartyResponse{
-							Message: msg,
-						}
-						if err := stream.Send(resp); err != nil {
-							log.Printf("send error %v\n", err)
-						}
-						log.Printf("finishing sending the message : %v\n", msg)
-					}(msg)
-				}
-				wg.Wait()
-			} else {
-				errMsg = "invalid userId. User has either not created the party or has not joined the party"
+			if gameParty.Players[requestData.UserId] != models.PlayerJoinedStatus && common.PartyLeader(gameParty) != requestData.UserId {
+				errMsg = "invalid userId. User is neither the party leader nor has joined the party"
 			}
 		} else {
 			errMsg = "party not found"
@@ -127,11 +123,244 @@ func (s userService) StreamPlayerJoinedStatus(requestData *gampepb.PlayerInParty
 	} else {
 		errMsg = "no parties created"
 	}
+	s.gameServer.Mutex.Unlock()
 
 	if errMsg != literals.EmptyString {
 		log.Println(errMsg)
 		return status.Errorf(codes.InvalidArgument, errMsg)
 	}
 
-	return nil
+	// the leader and every joined player get their own subscription.
+	// subscription ends when the party is over, the player leaves the party or the client closes the stream
+	subscription := s.eventBus.Subscribe(eventbus.PartyTopic(requestData.PartyId), requestData.UserId, sessionToken(stream.Context()))
+	defer s.eventBus.Unsubscribe(subscription)
+
+	return streamEvents(stream.Context(), subscription, presenceEventsOnly(func(event *models.PresenceEvent) error {
+		return stream.Send(&gampepb.PlayersInPartyResponse{
+			Message: presenceEventMessage(event),
+			Event:   toPresenceEventProto(event),
+		})
+	}))
+}
+
+// will stream the pending party invitations of the userId, followed by every new invitation,
+// invitation handled on another device and invited party that ended
+func (s userService) StreamPartyInvitations(requestData *gampepb.PartyInvitationsRequest, stream gampepb.UserService_StreamPartyInvitationsServer) error {
+
+	// stream is always opened for the authenticated caller
+	requestData.UserId, _ = auth.UserIdFromContext(stream.Context())
+
+	log.Printf("stream party invitations for userId : %v", requestData.UserId)
+
+	if requestData.UserId == literals.EmptyString {
+		errMsg := "empty userId"
+		log.Println(errMsg)
+		return status.Errorf(codes.InvalidArgument, errMsg)
+	}
+
+	// subscribe before reading the pending invitations so that no invitation sent in between is missed
+	subscription := s.eventBus.Subscribe(eventbus.InvitationTopic(requestData.UserId), requestData.UserId, sessionToken(stream.Context()))
+	defer s.eventBus.Unsubscribe(subscription)
+
+	for _, invitation := range GetPartyInvitationsServiceStruct().GetPendingInvitations(stream.Context(), requestData.UserId) {
+		err := stream.Send(&gampepb.PartyInvitationsResponse{
+			Event: toPresenceEventProto(&models.PresenceEvent{
+				Type:         models.PresenceEventPartyInviteSent,
+				ActorUserId:  invitation.InvitedBy,
+				TargetUserId: requestData.UserId,
+				PartyId:      invitation.PartyId,
+				NewStatus:    string(models.PlayerInvitedStatus),
+				Timestamp:    invitation.InvitedAt,
+			}),
+		})
+		if err != nil {
+			log.Printf("send error %v\n", err)
+			return err
+		}
+	}
+
+	return streamEvents(stream.Context(), subscription, presenceEventsOnly(func(event *models.PresenceEvent) error {
+		return stream.Send(&gampepb.PartyInvitationsResponse{
+			Event: toPresenceEventProto(event),
+		})
+	}))
+}
+
+// bidirectional party chat of the leader and the joined players. The first message of the client opens the chat of its partyId.
+// The history is sent first, then every new message. The chat ends when the player leaves the party or the party is over
+func (s userService) PartyChat(stream gampepb.UserService_PartyChatServer) error {
+
+	// chat is always opened for the authenticated caller
+	userId, _ := auth.UserIdFromContext(stream.Context())
+
+	firstRequest, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	partyId := firstRequest.PartyId
+
+	log.Printf("party chat of userId : %v for partyId : %v", userId, partyId)
+
+	svc := GetPartyChatService()
+	if err := svc.CheckMember(partyId, userId); err != nil {
+		log.Println(err)
+		return status.Errorf(codes.PermissionDenied, err.Error())
+	}
+
+	// subscribe before reading the history so that no message sent in between is missed.
+	// A message sent in between may be sent twice, clients can drop it by messageId
+	subscription := s.eventBus.Subscribe(eventbus.ChatTopic(partyId), userId, sessionToken(stream.Context()))
+	defer s.eventBus.Unsubscribe(subscription)
+
+	// replies to the client's messages are sent while the chat messages are streamed
+	var sendMutex sync.Mutex
+	send := func(response *gampepb.PartyChatResponse) error {
+		sendMutex.Lock()
+		defer sendMutex.Unlock()
+		return stream.Send(response)
+	}
+
+	for _, chatMessage := range svc.GetHistory(stream.Context(), partyId) {
+		if err := send(&gampepb.PartyChatResponse{Message: toChatMessageProto(chatMessage)}); err != nil {
+			log.Printf("send error %v\n", err)
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	handleRequest := func(request *gampepb.PartyChatRequest) {
+		var err error
+		if request.PartyId != literals.EmptyString && request.PartyId != partyId {
+			err = errors.New("chat is open for party " + partyId)
+		} else {
+			err = svc.SendMessage(ctx, partyId, userId, request.Text)
+		}
+		if err != nil {
+			send(&gampepb.PartyChatResponse{Error: err.Error()})
+		}
+	}
+
+	// the client's messages are read until it is done sending. Recv returns once the handler has returned
+	requests := make(chan *gampepb.PartyChatRequest)
+	go func() {
+		defer close(requests)
+		for {
+			request, err := stream.Recv()
+			if err != nil {
+				// a client that is done sending keeps getting the chat
+				if !errors.Is(err, io.EOF) {
+					cancel()
+				}
+				return
+			}
+			select {
+			case requests <- request:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// the client's messages are handled only while the chat is streamed, nothing is sent after the handler has returned
+	var handlerWG sync.WaitGroup
+	handlerWG.Add(1)
+	go func() {
+		defer handlerWG.Done()
+		// the first message may only open the chat
+		if firstRequest.Text != literals.EmptyString {
+			handleRequest(firstRequest)
+		}
+		for {
+			select {
+			case request, ok := <-requests:
+				if !ok {
+					return
+				}
+				handleRequest(request)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	err = streamEvents(ctx, subscription, func(event models.Event) error {
+		switch event := event.(type) {
+		case *models.ChatMessageEvent:
+			return send(&gampepb.PartyChatResponse{Message: toChatMessageProto(event.Message)})
+		case *models.PresenceEvent:
+			return send(&gampepb.PartyChatResponse{Event: toPresenceEventProto(event)})
+		}
+		return nil
+	})
+	cancel()
+	handlerWG.Wait()
+	return err
+}
+
+// will stream the direct messages sent to the userId while offline, followed by every new message sent by or to the userId.
+// Streamed messages of friends are marked delivered. The stream ends when the user logs out
+func (s userService) StreamDirectMessages(requestData *gampepb.DirectMessagesRequest, stream gampepb.UserService_StreamDirectMessagesServer) error {
+
+	// stream is always opened for the authenticated caller
+	requestData.UserId, _ = auth.UserIdFromContext(stream.Context())
+
+	log.Printf("stream direct messages for userId : %v", requestData.UserId)
+
+	if requestData.UserId == literals.EmptyString {
+		errMsg := "empty userId"
+		log.Println(errMsg)
+		return status.Errorf(codes.InvalidArgument, errMsg)
+	}
+
+	// subscribe before reading the undelivered messages so that no message sent in between is missed.
+	// A message sent in between may be sent twice, clients can drop it by messageId
+	subscription := s.eventBus.Subscribe(eventbus.MessageTopic(requestData.UserId), requestData.UserId, sessionToken(stream.Context()))
+	defer s.eventBus.Unsubscribe(subscription)
+
+	svc := GetDirectMessagesService()
+
+	undelivered, err := svc.GetUndeliveredMessages(stream.Context(), requestData.UserId)
+	if err != nil {
+		log.Printf("failed to fetch the undelivered direct messages %v\n", err)
+		return status.Errorf(codes.Internal, err.Error())
+	}
+	for _, directMessage := range undelivered {
+		if err := stream.Send(&gampepb.DirectMessagesResponse{Message: toDirectMessageProto(directMessage)}); err != nil {
+			log.Printf("send error %v\n", err)
+			return err
+		}
+	}
+	if err := svc.MarkDelivered(stream.Context(), undelivered...); err != nil {
+		log.Printf("failed to mark the direct messages delivered %v\n", err)
+	}
+
+	return streamEvents(stream.Context(), subscription, func(event models.Event) error {
+		switch event := event.(type) {
+		case *models.PresenceEvent:
+			return stream.Send(&gampepb.DirectMessagesResponse{Event: toPresenceEventProto(event)})
+		case *models.DirectMessageEvent:
+			if err := stream.Send(&gampepb.DirectMessagesResponse{Message: toDirectMessageProto(event.Message)}); err != nil {
+				return err
+			}
+			if event.Message.RecipientId == requestData.UserId {
+				if err := svc.MarkDelivered(stream.Context(), event.Message); err != nil {
+					log.Printf("failed to mark the direct message delivered %v\n", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// token of the session the stream was opened with. Logging out of the session ends its streams
+func sessionToken(ctx context.Context) string {
+	if session, ok := auth.SessionFromContext(ctx); ok {
+		return session.Token
+	}
+	return literals.EmptyString
 }
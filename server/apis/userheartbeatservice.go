@@ -0,0 +1,112 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/eventbus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type UserHeartbeatService interface {
+	RecordHeartbeat(ctx context.Context, userId string) error
+}
+
+var userHeartbeatServiceStruct UserHeartbeatService
+var userHeartbeatServiceOnce sync.Once
+
+type userHeartbeatService struct {
+	gameServer *models.GameServer
+	mongoDAO   mongodao.MongoDAO
+	eventBus   eventbus.EventBus
+}
+
+func InitUserHeartbeatService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus) UserHeartbeatService {
+	userHeartbeatServiceOnce.Do(func() {
+		userHeartbeatServiceStruct = &userHeartbeatService{
+			gameServer: gameSrvr,
+			mongoDAO:   mongodao,
+			eventBus:   evntBus,
+		}
+	})
+	return userHeartbeatServiceStruct
+}
+
+func GetUserHeartbeatServiceStruct() UserHeartbeatService {
+	if userHeartbeatServiceStruct == nil {
+		panic("User Heartbeat Service not initialized")
+	}
+	return userHeartbeatServiceStruct
+}
+
+// clients call this periodically while they are running. No request data is needed
+func UserHeartbeatHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := context.TODO()
+
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.UserHeartbeatResponseData{
+			Success: success,
+			Errors:  errStrings,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	userId, _ := auth.UserIdFromContext(r.Context())
+
+	if userId == literals.EmptyString {
+		fmt.Println("no user ID passed")
+		err := errors.New("no user ID passed")
+
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	svc := GetUserHeartbeatServiceStruct()
+	err = svc.RecordHeartbeat(ctx, userId)
+	if err != nil {
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+func (u userHeartbeatService) RecordHeartbeat(ctx context.Context, userId string) error {
+
+	err := u.mongoDAO.UpdateUserLastSeen(ctx, userId, time.Now())
+	if err != nil {
+		return err
+	}
+
+	users, err := u.mongoDAO.GetUserDetails(ctx, []string{userId})
+	if err != nil {
+		return err
+	}
+
+	// user was marked offline after missing heartbeats but the client is still alive. Bring the user back online
+	if users[0].Status == models.UserStatusOffline {
+		err = UpdateUsersStatusFromMembership(ctx, u.gameServer, u.mongoDAO, u.eventBus, []string{userId})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
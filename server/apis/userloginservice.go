@@ -9,27 +9,34 @@ import (
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/eventbus"
 	"net/http"
 	"sync"
+	"time"
 )
 
 type UserLoginService interface {
-	LogInUser(ctx context.Context, requestData *models.UserLogInRequestData) (*models.User, error)
+	LogInUser(ctx context.Context, requestData *models.UserLogInRequestData) (*models.User, *models.Session, error)
 }
 
 var userLoginServiceStruct UserLoginService
 var userLoginServiceOnce sync.Once
 
 type userLoginService struct {
-	mongoDAO   mongodao.MongoDAO
-	userServer *models.UserServer
+	gameServer     *models.GameServer
+	mongoDAO       mongodao.MongoDAO
+	eventBus       eventbus.EventBus
+	sessionManager auth.SessionManager
 }
 
-func InitUserLoginService(mongodao mongodao.MongoDAO, userSrvr *models.UserServer) UserLoginService {
+func InitUserLoginService(gameSrvr *models.GameServer, mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, sessionMgr auth.SessionManager) UserLoginService {
 	userLoginServiceOnce.Do(func() {
 		userLoginServiceStruct = &userLoginService{
-			mongoDAO:   mongodao,
-			userServer: userSrvr,
+			gameServer:     gameSrvr,
+			mongoDAO:       mongodao,
+			eventBus:       evntBus,
+			sessionManager: sessionMgr,
 		}
 	})
 	return userLoginServiceStruct
@@ -47,6 +54,7 @@ func UserLogInHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := context.TODO()
 
 	var userDetails *models.User
+	var session *models.Session
 	success := true
 	var responseStatusCode int = http.StatusOK
 	var errStrings []string
@@ -57,6 +65,7 @@ func UserLogInHandler(w http.ResponseWriter, r *http.Request) {
 			Success:     success,
 			Errors:      errStrings,
 			UserDetails: userDetails,
+			Session:     session,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(responseStatusCode)
@@ -81,7 +90,8 @@ func UserLogInHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Printf("Request data: %+v\n", requestData)
+	// never log the password
+	fmt.Printf("Request data: userId: %v\n", requestData.UserId)
 
 	if requestData.UserId == literals.EmptyString {
 		fmt.Println("no user ID passed")
@@ -94,7 +104,7 @@ func UserLogInHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	svc := GetUserLoginServiceStruct()
-	userDetails, err = svc.LogInUser(ctx, requestData)
+	userDetails, session, err = svc.LogInUser(ctx, requestData)
 	if err != nil {
 		success = false
 		responseStatusCode = http.StatusInternalServerError
@@ -106,7 +116,7 @@ func UserLogInHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (u userLoginService) LogInUser(ctx context.Context, requestData *models.UserLogInRequestData) (*models.User, error) {
+func (u userLoginService) LogInUser(ctx context.Context, requestData *models.UserLogInRequestData) (*models.User, *models.Session, error) {
 
 	var users []*models.User
 	var err error
@@ -115,36 +125,47 @@ func (u userLoginService) LogInUser(ctx context.Context, requestData *models.Use
 	// check user creds
 	isUserPresent, err = u.mongoDAO.CheckUserCreds(ctx, requestData.UserId, requestData.Password)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if isUserPresent {
-		// update the status to 'idle'
-		_, err = u.mongoDAO.UpdateUsersStatus(ctx, []string{requestData.UserId}, models.UserStatusIdle)
+		// logging in counts as the first heartbeat. Stored before the status so that
+		// a heartbeat sweep in between does not see the old lastSeen and mark the user offline again
+		err = u.mongoDAO.UpdateUserLastSeen(ctx, requestData.UserId, time.Now())
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		// update the status to 'idle', or 'in-game' if the user is still in a party
+		err = UpdateUsersStatusFromMembership(ctx, u.gameServer, u.mongoDAO, u.eventBus, []string{requestData.UserId})
+		if err != nil {
+			return nil, nil, err
 		}
 
 		users, err = u.mongoDAO.GetUserDetails(ctx, []string{requestData.UserId})
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+
+		session, err := u.sessionManager.CreateSession(requestData.UserId)
+		if err != nil {
+			return nil, nil, err
 		}
-		go AsyncMsgPublishToFriend(ctx, u, requestData.UserId)
-		return users[0], nil
+
+		return users[0], session, nil
 	}
-	return nil, err
+	return nil, nil, err
 }
 
-func AsyncMsgPublishToFriend(ctx context.Context, u userLoginService, userId string) {
+// publish the event to every friend of userId. Friends listening for status updates will receive it
+func AsyncMsgPublishToFriend(ctx context.Context, mongoDAO mongodao.MongoDAO, eventBus eventbus.EventBus, userId string, event *models.PresenceEvent) {
 	// find this user's friends
-	friends, friendFetchErr := u.mongoDAO.GetUserFriends(ctx, userId)
+	friends, friendFetchErr := mongoDAO.GetUserFriends(ctx, userId)
 	if friendFetchErr != nil {
 		return
 	}
 	for _, friend := range friends {
-		if u.userServer != nil && u.userServer.UserDetails != nil && u.userServer.UserDetails[friend.FriendId] != nil && u.userServer.UserDetails[friend.FriendId].FriendOnlineUpdateMsg != nil {
-			u.userServer.UserDetails[friend.FriendId].FriendOnlineUpdateMsg <- fmt.Sprintf("%v is now online", userId)
-		}
+		eventBus.Publish(eventbus.UserTopic(friend.FriendId), event)
 	}
 
 }
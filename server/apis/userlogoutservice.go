@@ -9,6 +9,8 @@ import (
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/eventbus"
 	"net/http"
 	"sync"
 )
@@ -21,15 +23,17 @@ var userLogOutServiceStruct UserLogOutService
 var userLogOutServiceOnce sync.Once
 
 type userLogOutService struct {
-	mongoDAO   mongodao.MongoDAO
-	userServer *models.UserServer
+	mongoDAO       mongodao.MongoDAO
+	eventBus       eventbus.EventBus
+	sessionManager auth.SessionManager
 }
 
-func InitUserLogOutService(mongodao mongodao.MongoDAO, userSrvr *models.UserServer) UserLogOutService {
+func InitUserLogOutService(mongodao mongodao.MongoDAO, evntBus eventbus.EventBus, sessionMgr auth.SessionManager) UserLogOutService {
 	userLogOutServiceOnce.Do(func() {
 		userLogOutServiceStruct = &userLogOutService{
-			mongoDAO:   mongodao,
-			userServer: userSrvr,
+			mongoDAO:       mongodao,
+			eventBus:       evntBus,
+			sessionManager: sessionMgr,
 		}
 	})
 	return userLogOutServiceStruct
@@ -79,6 +83,11 @@ func UserLogOutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the acting user is always the authenticated caller, never the one sent in the request data
+	session, _ := auth.SessionFromContext(r.Context())
+	requestData.UserId = session.UserId
+	requestData.SessionToken = session.Token
+
 	fmt.Printf("Request data: %+v\n", requestData)
 
 	if requestData.UserId == literals.EmptyString {
@@ -105,24 +114,31 @@ func UserLogOutHandler(w http.ResponseWriter, r *http.Request) {
 
 func (u userLogOutService) LogOutUser(ctx context.Context, requestData *models.UserLogOutRequestData) error {
 
-	result, err := u.mongoDAO.UpdateUsersStatus(ctx, []string{requestData.UserId}, models.UserStatusOffline)
+	// the token used to log out cannot be used anymore and the streams opened with it end,
+	// whatever happens to the status of the user
+	u.sessionManager.RevokeToken(requestData.SessionToken)
+	fmt.Printf("%v logging out. closing the streams of the session\n", requestData.UserId)
+	u.eventBus.UnsubscribeSession(requestData.SessionToken)
+
+	// the user stays online while another device is logged in
+	if u.sessionManager.HasSessions(requestData.UserId) {
+		return nil
+	}
+
+	// parties led by the user get a new leader or end
+	err := GetGamePartyLeaderService().LeaveLedParties(ctx, requestData.UserId)
+	if err != nil {
+		return err
+	}
+
+	// a user already marked offline by the heartbeat sweep is logged out as well
+	result, err := UpdateUsersStatusAndNotifyFriends(ctx, u.mongoDAO, u.eventBus, []string{requestData.UserId}, models.UserStatusOffline)
 	if err != nil {
 		return err
 	}
 	if result.MatchedCount == 0 {
 		return errors.New("invalid userId")
 	}
-	if result.ModifiedCount == 0 {
-		return errors.New("failed to logout")
-	}
-
-	// asynchronously make the user server channel as nil if present
-	go func() {
-		if u.userServer != nil && u.userServer.UserDetails != nil && u.userServer.UserDetails[requestData.UserId] != nil {
-			fmt.Printf("%v logging out. closing the user online status channel\n", requestData.UserId)
-			close(u.userServer.UserDetails[requestData.UserId].FriendOnlineUpdateMsg)
-		}
-	}()
 
 	return nil
 }
@@ -0,0 +1,162 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores anything after 72 bytes
+)
+
+type UserRegisterService interface {
+	ValidateRequest(requestData *models.UserRegisterRequestData) []string
+	RegisterUser(ctx context.Context, requestData *models.UserRegisterRequestData) (*models.User, error)
+}
+
+var userRegisterServiceStruct UserRegisterService
+var userRegisterServiceOnce sync.Once
+
+type userRegisterService struct {
+	mongoDAO mongodao.MongoDAO
+}
+
+func InitUserRegisterService(mongodao mongodao.MongoDAO) UserRegisterService {
+	userRegisterServiceOnce.Do(func() {
+		userRegisterServiceStruct = &userRegisterService{
+			mongoDAO: mongodao,
+		}
+	})
+	return userRegisterServiceStruct
+}
+
+func GetUserRegisterServiceStruct() UserRegisterService {
+	if userRegisterServiceStruct == nil {
+		panic("User Register Service not initialized")
+	}
+	return userRegisterServiceStruct
+}
+
+func (u userRegisterService) ValidateRequest(requestData *models.UserRegisterRequestData) []string {
+	var errs []error
+	var errorString []string
+
+	if strings.TrimSpace(requestData.UserId) == literals.EmptyString {
+		errs = append(errs, errors.New("empty userId in the request data"))
+	}
+
+	if len(requestData.Password) < minPasswordLength {
+		errs = append(errs, errors.New("password should have at least "+fmt.Sprint(minPasswordLength)+" characters"))
+	} else if len(requestData.Password) > maxPasswordLength {
+		errs = append(errs, errors.New("password should not have more than "+fmt.Sprint(maxPasswordLength)+" characters"))
+	}
+
+	if strings.TrimSpace(requestData.Name) == literals.EmptyString {
+		errs = append(errs, errors.New("empty name in the request data"))
+	}
+
+	if !strings.Contains(requestData.Email, "@") {
+		errs = append(errs, errors.New("invalid email in the request data"))
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			errorString = append(errorString, err.Error())
+		}
+		return errorString
+	}
+
+	return nil
+}
+
+func UserRegisterHandler(w http.ResponseWriter, r *http.Request) {
+
+	ctx := context.TODO()
+
+	var userDetails *models.User
+	success := true
+	var responseStatusCode int = http.StatusOK
+	var errStrings []string
+	var err error
+
+	defer func() {
+		result := models.UserRegisterResponseData{
+			Success:     success,
+			Errors:      errStrings,
+			UserDetails: userDetails,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseStatusCode)
+		json.NewEncoder(w).Encode(result)
+	}()
+
+	requestData := &models.UserRegisterRequestData{}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		fmt.Printf("failed to read message for user register request: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+	err = json.Unmarshal(data, requestData)
+	if err != nil {
+		fmt.Printf("failed to unmarshal message for user register request: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+
+	// never log the password
+	fmt.Printf("Request data: userId: %v, name: %v, email: %v, level: %v\n", requestData.UserId, requestData.Name, requestData.Email, requestData.Level)
+
+	svc := GetUserRegisterServiceStruct()
+
+	errStrings = svc.ValidateRequest(requestData)
+	if errStrings != nil {
+		success = false
+		responseStatusCode = http.StatusBadRequest
+		return
+	}
+
+	userDetails, err = svc.RegisterUser(ctx, requestData)
+	if err != nil {
+		fmt.Printf("failed to register user: %v\n", err)
+		success = false
+		responseStatusCode = http.StatusInternalServerError
+		if errors.Is(err, mongodao.ErrUserAlreadyExists) {
+			responseStatusCode = http.StatusConflict
+		}
+		errStrings = append(errStrings, err.Error())
+		return
+	}
+}
+
+func (u userRegisterService) RegisterUser(ctx context.Context, requestData *models.UserRegisterRequestData) (*models.User, error) {
+
+	user := &models.User{
+		ID:     strings.TrimSpace(requestData.UserId),
+		Name:   strings.TrimSpace(requestData.Name),
+		Email:  strings.TrimSpace(requestData.Email),
+		Level:  requestData.Level,
+		Status: models.UserStatusOffline, // user becomes 'idle' on login
+	}
+
+	err := u.mongoDAO.CreateUser(ctx, user, requestData.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
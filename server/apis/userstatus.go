@@ -0,0 +1,125 @@
+package apis
+
+import (
+	"context"
+	"lite-social-presence-system/models"
+	"lite-social-presence-system/mongodao"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// update the status of the users and let the friends of every user whose status actually changed know about it.
+// Every change of models.UserStatus has to go through here so that the friend lists stay live
+func UpdateUsersStatusAndNotifyFriends(ctx context.Context, mongoDAO mongodao.MongoDAO, eventBus eventbus.EventBus, userIds []string, status models.UserStatus) (*mongo.UpdateResult, error) {
+
+	if len(userIds) == 0 {
+		return &mongo.UpdateResult{}, nil
+	}
+
+	// the same user can be listed more than once. e.g. party leader who is also a player
+	seen := make(map[string]bool)
+	var uniqueUserIds []string
+	for _, userId := range userIds {
+		if !seen[userId] {
+			seen[userId] = true
+			uniqueUserIds = append(uniqueUserIds, userId)
+		}
+	}
+	userIds = uniqueUserIds
+
+	// status before the update is sent to the friends along with the new status
+	users, err := mongoDAO.GetUserDetails(ctx, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := mongoDAO.UpdateUsersStatus(ctx, userIds, status)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		if user.Status == status {
+			continue
+		}
+		go AsyncMsgPublishToFriend(context.TODO(), mongoDAO, eventBus, user.ID, &models.PresenceEvent{
+			Type:        userStatusEventType(user.Status, status),
+			ActorUserId: user.ID,
+			OldStatus:   string(user.Status),
+			NewStatus:   string(status),
+			Timestamp:   time.Now(),
+		})
+	}
+
+	return result, nil
+}
+
+// mark the stale users offline and let their friends know. Users who sent a heartbeat since they were
+// fetched are left as they are, so that no one is reported offline right after being seen
+func MarkStaleUsersOfflineAndNotifyFriends(ctx context.Context, mongoDAO mongodao.MongoDAO, eventBus eventbus.EventBus, staleUsers []*models.User, lastSeenBefore time.Time) ([]string, error) {
+
+	var staleUserIds []string
+	for _, user := range staleUsers {
+		staleUserIds = append(staleUserIds, user.ID)
+	}
+
+	offlineUserIds, err := mongoDAO.MarkStaleUsersOffline(ctx, staleUserIds, lastSeenBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	isOffline := make(map[string]bool)
+	for _, userId := range offlineUserIds {
+		isOffline[userId] = true
+	}
+	for _, user := range staleUsers {
+		if !isOffline[user.ID] {
+			continue
+		}
+		go AsyncMsgPublishToFriend(context.TODO(), mongoDAO, eventBus, user.ID, &models.PresenceEvent{
+			Type:        models.PresenceEventUserOffline,
+			ActorUserId: user.ID,
+			OldStatus:   string(user.Status),
+			NewStatus:   string(models.UserStatusOffline),
+			Timestamp:   time.Now(),
+		})
+	}
+
+	return offlineUserIds, nil
+}
+
+// set the users in-game if they are in a party, idle otherwise. Used whenever the party membership of the users changes
+func UpdateUsersStatusFromMembership(ctx context.Context, gameServer *models.GameServer, mongoDAO mongodao.MongoDAO, eventBus eventbus.EventBus, userIds []string) error {
+
+	var inGameUserIds []string
+	var idleUserIds []string
+	gameServer.Mutex.Lock()
+	for _, userId := range userIds {
+		if _, ok := common.CurrentParty(gameServer, userId); ok {
+			inGameUserIds = append(inGameUserIds, userId)
+		} else {
+			idleUserIds = append(idleUserIds, userId)
+		}
+	}
+	gameServer.Mutex.Unlock()
+
+	_, err := UpdateUsersStatusAndNotifyFriends(ctx, mongoDAO, eventBus, inGameUserIds, models.UserStatusInGame)
+	if err != nil {
+		return err
+	}
+	_, err = UpdateUsersStatusAndNotifyFriends(ctx, mongoDAO, eventBus, idleUserIds, models.UserStatusIdle)
+	return err
+}
+
+func userStatusEventType(oldStatus models.UserStatus, newStatus models.UserStatus) models.PresenceEventType {
+	switch {
+	case newStatus == models.UserStatusOffline:
+		return models.PresenceEventUserOffline
+	case oldStatus == models.UserStatusOffline || oldStatus == models.UserStatusUndefined:
+		return models.PresenceEventUserOnline
+	}
+	return models.PresenceEventUserStatusChanged
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"lite-social-presence-system/models"
+	"sync"
+	"time"
+)
+
+const tokenBytes = 32
+
+var ErrInvalidToken = errors.New("invalid or expired session token")
+
+// SessionManager issues opaque session tokens on login and resolves them back to the user.
+// Sessions are stored server-side so that they can be revoked on logout.
+type SessionManager interface {
+	CreateSession(userId string) (*models.Session, error)
+	ValidateToken(token string) (*models.Session, error)
+	RevokeToken(token string)
+	HasSessions(userId string) bool
+}
+
+var sessionManagerStruct SessionManager
+var sessionManagerOnce sync.Once
+
+type sessionManager struct {
+	sessionTTL time.Duration
+	sessions   map[string]*models.Session // keyed by token
+	mutex      sync.RWMutex
+}
+
+func InitSessionManager(sessionTTL time.Duration) SessionManager {
+	sessionManagerOnce.Do(func() {
+		sessionManagerStruct = &sessionManager{
+			sessionTTL: sessionTTL,
+			sessions:   make(map[string]*models.Session),
+		}
+	})
+	return sessionManagerStruct
+}
+
+func GetSessionManager() SessionManager {
+	if sessionManagerStruct == nil {
+		panic("Session Manager not initialized")
+	}
+	return sessionManagerStruct
+}
+
+func (s *sessionManager) CreateSession(userId string) (*models.Session, error) {
+	randomBytes := make([]byte, tokenBytes)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		Token:     hex.EncodeToString(randomBytes),
+		UserId:    userId,
+		ExpiresAt: now.Add(s.sessionTTL),
+	}
+
+	s.mutex.Lock()
+	// drop sessions that have expired so that the map does not keep growing
+	for token, existingSession := range s.sessions {
+		if now.After(existingSession.ExpiresAt) {
+			delete(s.sessions, token)
+		}
+	}
+	s.sessions[session.Token] = session
+	s.mutex.Unlock()
+
+	return session, nil
+}
+
+func (s *sessionManager) ValidateToken(token string) (*models.Session, error) {
+	s.mutex.RLock()
+	session, ok := s.sessions[token]
+	s.mutex.RUnlock()
+
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().After(session.ExpiresAt) {
+		s.RevokeToken(token)
+		return nil, ErrInvalidToken
+	}
+	return session, nil
+}
+
+func (s *sessionManager) RevokeToken(token string) {
+	s.mutex.Lock()
+	delete(s.sessions, token)
+	s.mutex.Unlock()
+}
+
+// true while the user is still logged in on another device
+func (s *sessionManager) HasSessions(userId string) bool {
+	now := time.Now()
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, session := range s.sessions {
+		if session.UserId == userId && !now.After(session.ExpiresAt) {
+			return true
+		}
+	}
+	return false
+}
+
+type sessionContextKey struct{}
+
+// returns a copy of ctx carrying the authenticated session
+func ContextWithSession(ctx context.Context, session *models.Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// returns the session resolved by the HTTP middleware or the gRPC interceptors
+func SessionFromContext(ctx context.Context) (*models.Session, bool) {
+	session, ok := ctx.Value(sessionContextKey{}).(*models.Session)
+	return session, ok
+}
+
+// returns the userId of the authenticated caller
+func UserIdFromContext(ctx context.Context) (string, bool) {
+	session, ok := SessionFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return session.UserId, true
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lite-social-presence-system/literals"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type unauthorizedResponseData struct {
+	Success bool     `json:"success"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// extract the token from a "Bearer <token>" authorization value
+func bearerToken(authorization string) string {
+	token, found := strings.CutPrefix(authorization, literals.BearerPrefix)
+	if !found {
+		return literals.EmptyString
+	}
+	return strings.TrimSpace(token)
+}
+
+func resolveSession(ctx context.Context, authorization string) (context.Context, error) {
+	token := bearerToken(authorization)
+	if token == literals.EmptyString {
+		return nil, ErrInvalidToken
+	}
+
+	session, err := GetSessionManager().ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return ContextWithSession(ctx, session), nil
+}
+
+// HTTP middleware that rejects requests without a valid session token
+// and puts the caller's session in the request context
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := resolveSession(r.Context(), r.Header.Get(literals.AuthorizationHeader))
+		if err != nil {
+			fmt.Printf("rejecting unauthenticated request to %v: %v\n", r.URL.Path, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(unauthorizedResponseData{
+				Success: false,
+				Errors:  []string{err.Error()},
+			})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func sessionFromIncomingMetadata(ctx context.Context) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, ErrInvalidToken.Error())
+	}
+
+	var authorization string
+	if values := md.Get(literals.AuthorizationMetadataKey); len(values) > 0 {
+		authorization = values[0]
+	}
+
+	ctx, err := resolveSession(ctx, authorization)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return ctx, nil
+}
+
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := sessionFromIncomingMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// wraps the server stream so that handlers see the context carrying the session
+type authenticatedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func StreamServerInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := sessionFromIncomingMetadata(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedServerStream{
+		ServerStream: stream,
+		ctx:          ctx,
+	})
+}
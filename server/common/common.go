@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
 	"time"
@@ -11,8 +12,163 @@ import (
 
 // create gameServer file and push all that over there
 
-// var PartyDuration time.Duration = 900000 * time.Millisecond // 15 minutes = 900 seconds = 9,00,000 in milliseconds
-var PartyDuration time.Duration = 900000000 * time.Millisecond // 10 days
+// used when default_party_duration, min_party_duration and max_party_duration are not set in the config
+// var DefaultPartyDuration time.Duration = 900000 * time.Millisecond // 15 minutes = 900 seconds = 9,00,000 in milliseconds
+var DefaultPartyDuration time.Duration = 900000000 * time.Millisecond // 10 days
+var DefaultMinPartyDuration time.Duration = 5 * time.Minute
+var DefaultMaxPartyDuration time.Duration = 30 * 24 * time.Hour
+
+// used when max_party_size is not set in the config
+var DefaultMaxPartySize int = 8
+
+// bounds for the duration of a game party
+type PartyDurationConfig struct {
+	Default time.Duration // used when the party leader does not ask for a duration
+	Min     time.Duration
+	Max     time.Duration // extensions included
+}
+
+// used when session_ttl is not set in the config
+var DefaultSessionTTL time.Duration = 24 * time.Hour
+
+// used when heartbeat_timeout and heartbeat_sweep_interval are not set in the config
+var DefaultHeartbeatTimeout time.Duration = 90 * time.Second
+var DefaultHeartbeatSweepInterval time.Duration = 30 * time.Second
+
+// used when invite_ttl and invite_sweep_interval are not set in the config
+var DefaultInviteTTL time.Duration = 24 * time.Hour
+var DefaultInviteSweepInterval time.Duration = 30 * time.Second
+
+// used when ready_check_timeout and ready_check_sweep_interval are not set in the config
+var DefaultReadyCheckTimeout time.Duration = 30 * time.Second
+var DefaultReadyCheckSweepInterval time.Duration = 1 * time.Second
+
+// used when chat_history_size and chat_max_message_size are not set in the config
+var DefaultChatHistorySize int = 50
+var DefaultChatMaxMessageSize int = 1024
+
+// used when direct_message_max_size is not set in the config
+var DefaultDirectMessageMaxSize int = 1024
+
+// used when shutdown_timeout is not set in the config
+var DefaultShutdownTimeout time.Duration = 30 * time.Second
+
+// current leader of the party. Parties created before leadership could be handed over are led by their creator
+func PartyLeader(gameParty *models.GameParty) string {
+	if gameParty.Leader != literals.EmptyString {
+		return gameParty.Leader
+	}
+	return gameParty.CreatedBy
+}
+
+// party the user is currently in, as the leader or a joined player. gameServer.Mutex has to be held
+func CurrentParty(gameServer *models.GameServer, userId string) (string, bool) {
+	partyId, ok := gameServer.UserParties[userId]
+	return partyId, ok
+}
+
+// forget that the user is in the party. Does nothing if the user has moved to another party. gameServer.Mutex has to be held
+func LeaveParty(gameServer *models.GameServer, userId string, partyId string) {
+	if gameServer.UserParties[userId] == partyId {
+		delete(gameServer.UserParties, userId)
+	}
+}
+
+// who can join the party without being invited. Parties created before visibilities existed are invite-only
+func PartyVisibility(gameParty *models.GameParty) models.GamePartyVisibility {
+	if gameParty.Visibility != literals.EmptyString {
+		return gameParty.Visibility
+	}
+	return models.GamePartyVisibilityInviteOnly
+}
+
+// what ReconcileExpiredGameParties repaired
+type ReconciliationReport struct {
+	EndedPartyIds  []string // active parties whose duration was over
+	ResetUserIds   []string // users moved from in-game to idle
+	SkippedUserIds []string // users of the ended parties who are still in another active party or not in-game anymore
+}
+
+// end the parties whose duration passed while the server was down and reset the status of their users.
+// Has to run before NewGameServer so that only parties that are really active are loaded
+func ReconcileExpiredGameParties(ctx context.Context, mgDAO mongodao.MongoDAO) (*ReconciliationReport, error) {
+	report := &ReconciliationReport{}
+
+	expiredParties, err := mgDAO.FetchGamePartiesToBeEnded(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(expiredParties) == 0 {
+		return report, nil
+	}
+
+	// leader and joined players of the expired parties
+	affectedUsers := make(map[string]bool)
+	for _, gameParty := range expiredParties {
+		report.EndedPartyIds = append(report.EndedPartyIds, gameParty.PartyId)
+		affectedUsers[PartyLeader(gameParty)] = true
+		for userId, status := range gameParty.Players {
+			if status == models.PlayerJoinedStatus {
+				affectedUsers[userId] = true
+			}
+		}
+	}
+
+	err = mgDAO.UpdateGamePartyStatus(ctx, report.EndedPartyIds, models.GamePartyStatusOver)
+	if err != nil {
+		return nil, err
+	}
+
+	// users still playing in a party that is not over keep their status
+	activeParties, err := mgDAO.FetchActiveGameParties(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stillInGame := make(map[string]bool)
+	for _, gameParty := range activeParties {
+		stillInGame[PartyLeader(gameParty)] = true
+		for userId, status := range gameParty.Players {
+			if status == models.PlayerJoinedStatus {
+				stillInGame[userId] = true
+			}
+		}
+	}
+
+	var candidateUserIds []string
+	for userId := range affectedUsers {
+		if stillInGame[userId] {
+			report.SkippedUserIds = append(report.SkippedUserIds, userId)
+			continue
+		}
+		candidateUserIds = append(candidateUserIds, userId)
+	}
+	if len(candidateUserIds) == 0 {
+		return report, nil
+	}
+
+	users, err := mgDAO.GetUserDetails(ctx, candidateUserIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		// users who went offline keep their status. heartbeats bring them back
+		if user.Status == models.UserStatusInGame {
+			report.ResetUserIds = append(report.ResetUserIds, user.ID)
+		} else {
+			report.SkippedUserIds = append(report.SkippedUserIds, user.ID)
+		}
+	}
+
+	// no stream is open yet, so there is no one to notify
+	if len(report.ResetUserIds) > 0 {
+		_, err = mgDAO.UpdateUsersStatus(ctx, report.ResetUserIds, models.UserStatusIdle)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
 
 func NewGameServer(mgDAO mongodao.MongoDAO) (*models.GameServer, error) {
 	var gameParties []*models.GameParty
@@ -26,28 +182,40 @@ func NewGameServer(mgDAO mongodao.MongoDAO) (*models.GameServer, error) {
 	}
 	if gameParties == nil {
 		return &models.GameServer{
-			Parties: make(map[string]*models.GameParty),
+			Parties:     make(map[string]*models.GameParty),
+			UserParties: make(map[string]string),
+			JoinCodes:   make(map[string]string),
+			ChatHistory: make(map[string][]*models.ChatMessage),
 		}, nil
 	}
 
 	parties := make(map[string]*models.GameParty)
+	userParties := make(map[string]string)
+	joinCodes := make(map[string]string)
 
 	for _, gameParty := range gameParties {
 		parties[gameParty.PartyId] = gameParty
+
+		// users who ended up in several parties before this was enforced are tracked in one of them
+		userParties[PartyLeader(gameParty)] = gameParty.PartyId
+		for userId, status := range gameParty.Players {
+			if status == models.PlayerJoinedStatus {
+				userParties[userId] = gameParty.PartyId
+			}
+		}
+		if gameParty.JoinCode != literals.EmptyString {
+			joinCodes[gameParty.JoinCode] = gameParty.PartyId
+		}
 	}
 
 	return &models.GameServer{
-		Parties: parties,
+		Parties:     parties,
+		UserParties: userParties,
+		JoinCodes:   joinCodes,
+		ChatHistory: make(map[string][]*models.ChatMessage),
 	}, nil
 }
 
-func NewUserServer() *models.UserServer {
-	return &models.UserServer{
-		UserDetails: make(map[string]*models.UserDetails),
-	}
-
-}
-
 // remove data from one slice and append to the other
 func RemoveAndAppendSlice(dataToRemove string, s1 []string, s2 []string) ([]string, []string, error) {
 
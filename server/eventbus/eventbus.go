@@ -0,0 +1,225 @@
+package eventbus
+
+import (
+	"lite-social-presence-system/literals"
+	"lite-social-presence-system/models"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// events are buffered per subscription so that a slow stream does not block the publisher.
+// Once the buffer is full, new events for that subscription are dropped
+const subscriptionBufferSize = 32
+
+const (
+	userTopicPrefix       = "user:"
+	partyTopicPrefix      = "party:"
+	invitationTopicPrefix = "invitations:"
+	chatTopicPrefix       = "chat:"
+	messageTopicPrefix    = "messages:"
+)
+
+// topic on which the friend status updates for userId are published
+func UserTopic(userId string) string {
+	return userTopicPrefix + userId
+}
+
+// topic on which the events of a game party are published
+func PartyTopic(partyId string) string {
+	return partyTopicPrefix + partyId
+}
+
+// topic on which the party invitations sent to userId are published
+func InvitationTopic(userId string) string {
+	return invitationTopicPrefix + userId
+}
+
+// topic on which the chat messages of a game party are published
+func ChatTopic(partyId string) string {
+	return chatTopicPrefix + partyId
+}
+
+// topic on which the direct messages sent by and to userId are published
+func MessageTopic(userId string) string {
+	return messageTopicPrefix + userId
+}
+
+// EventBus fans out every event published on a topic to all the subscriptions of that topic.
+// Every stream gets its own subscription, so one subscriber never takes events away from another
+type EventBus interface {
+	Subscribe(topic string, subscriberId string, sessionToken string) *Subscription
+	Unsubscribe(subscription *Subscription)
+	UnsubscribeSubscriber(topic string, subscriberId string)
+	UnsubscribeSession(sessionToken string)
+	Publish(topic string, event models.Event)
+	CloseTopic(topic string)
+	CloseAll(lastEvent models.Event)
+}
+
+type Subscription struct {
+	Topic        string
+	SubscriberId string              // user listening on the subscription
+	SessionToken string              // session of the device that opened the stream
+	Events       <-chan models.Event // closed when the subscription ends
+	events       chan models.Event
+}
+
+var eventBusStruct EventBus
+var eventBusOnce sync.Once
+
+type eventBus struct {
+	subscriptions map[string]map[*Subscription]struct{} // subscriptions keyed by topic
+	closed        bool                                  // set by CloseAll. No new subscriptions are accepted afterwards
+	mutex         sync.RWMutex
+}
+
+func InitEventBus() EventBus {
+	eventBusOnce.Do(func() {
+		eventBusStruct = NewEventBus()
+	})
+	return eventBusStruct
+}
+
+// event bus that is not shared. InitEventBus has to be used by the server
+func NewEventBus() EventBus {
+	return &eventBus{
+		subscriptions: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+func GetEventBus() EventBus {
+	if eventBusStruct == nil {
+		panic("Event Bus not initialized")
+	}
+	return eventBusStruct
+}
+
+func (b *eventBus) Subscribe(topic string, subscriberId string, sessionToken string) *Subscription {
+	events := make(chan models.Event, subscriptionBufferSize)
+	subscription := &Subscription{
+		Topic:        topic,
+		SubscriberId: subscriberId,
+		SessionToken: sessionToken,
+		Events:       events,
+		events:       events,
+	}
+
+	b.mutex.Lock()
+	if b.closed {
+		// server is shutting down, the subscription ends right away
+		close(events)
+		b.mutex.Unlock()
+		return subscription
+	}
+	if b.subscriptions[topic] == nil {
+		b.subscriptions[topic] = make(map[*Subscription]struct{})
+	}
+	b.subscriptions[topic][subscription] = struct{}{}
+	b.mutex.Unlock()
+
+	return subscription
+}
+
+// safe to call more than once and after the topic has been closed
+func (b *eventBus) Unsubscribe(subscription *Subscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.subscriptions[subscription.Topic][subscription]; !ok {
+		return
+	}
+	delete(b.subscriptions[subscription.Topic], subscription)
+	if len(b.subscriptions[subscription.Topic]) == 0 {
+		delete(b.subscriptions, subscription.Topic)
+	}
+	close(subscription.events)
+}
+
+// end the subscriptions of one subscriber on the topic, leaving the other subscribers untouched.
+// e.g. a player leaving the party stops getting its events while the rest of the party keeps streaming
+func (b *eventBus) UnsubscribeSubscriber(topic string, subscriberId string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for subscription := range b.subscriptions[topic] {
+		if subscription.SubscriberId != subscriberId {
+			continue
+		}
+		delete(b.subscriptions[topic], subscription)
+		close(subscription.events)
+	}
+	if len(b.subscriptions[topic]) == 0 {
+		delete(b.subscriptions, topic)
+	}
+}
+
+// end every subscription opened with the session, on any topic. e.g. a device logging out stops
+// streaming while the other devices of the same user keep their streams
+func (b *eventBus) UnsubscribeSession(sessionToken string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for topic, subscriptions := range b.subscriptions {
+		for subscription := range subscriptions {
+			if subscription.SessionToken != sessionToken {
+				continue
+			}
+			delete(subscriptions, subscription)
+			close(subscription.events)
+		}
+		if len(subscriptions) == 0 {
+			delete(b.subscriptions, topic)
+		}
+	}
+}
+
+// never blocks. Subscriptions whose buffer is full miss the event
+func (b *eventBus) Publish(topic string, event models.Event) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for subscription := range b.subscriptions[topic] {
+		select {
+		case subscription.events <- event:
+		default:
+			logrus.WithFields(logrus.Fields{
+				literals.LLTopic:     topic,
+				literals.LLEventType: event.EventType(),
+			}).Warn("subscription buffer full, dropping event")
+		}
+	}
+}
+
+// end every subscription of the topic. Streams reading from them see the channel closed
+func (b *eventBus) CloseTopic(topic string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for subscription := range b.subscriptions[topic] {
+		close(subscription.events)
+	}
+	delete(b.subscriptions, topic)
+}
+
+// send lastEvent to every subscription and end all of them. Used when the server shuts down
+func (b *eventBus) CloseAll(lastEvent models.Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for topic, subscriptions := range b.subscriptions {
+		for subscription := range subscriptions {
+			select {
+			case subscription.events <- lastEvent:
+			default:
+				logrus.WithFields(logrus.Fields{
+					literals.LLTopic:     topic,
+					literals.LLEventType: lastEvent.EventType(),
+				}).Warn("subscription buffer full, dropping event")
+			}
+			close(subscription.events)
+		}
+		delete(b.subscriptions, topic)
+	}
+}
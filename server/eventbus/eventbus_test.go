@@ -0,0 +1,58 @@
+package eventbus
+
+import (
+	"lite-social-presence-system/models"
+	"testing"
+)
+
+func TestPublishFansOutToEverySubscription(t *testing.T) {
+	bus := NewEventBus()
+	phone := bus.Subscribe(PartyTopic("party1"), "u1", "phone")
+	laptop := bus.Subscribe(PartyTopic("party1"), "u1", "laptop")
+	friend := bus.Subscribe(PartyTopic("party1"), "u2", "u2-phone")
+	otherParty := bus.Subscribe(PartyTopic("party2"), "u1", "phone")
+
+	event := &models.PresenceEvent{Type: models.PresenceEventPlayerJoinedParty, PartyId: "party1"}
+	bus.Publish(PartyTopic("party1"), event)
+
+	for name, subscription := range map[string]*Subscription{"phone": phone, "laptop": laptop, "friend": friend} {
+		select {
+		case received := <-subscription.Events:
+			if received != event {
+				t.Errorf("%v received %v, want %v", name, received, event)
+			}
+		default:
+			t.Errorf("%v did not receive the event", name)
+		}
+	}
+	select {
+	case received := <-otherParty.Events:
+		t.Errorf("subscription of another topic received %v", received)
+	default:
+	}
+}
+
+func TestUnsubscribeSessionEndsOnlyItsSubscriptions(t *testing.T) {
+	bus := NewEventBus()
+	phoneParty := bus.Subscribe(PartyTopic("party1"), "u1", "phone")
+	phoneChat := bus.Subscribe(ChatTopic("party1"), "u1", "phone")
+	laptopParty := bus.Subscribe(PartyTopic("party1"), "u1", "laptop")
+
+	bus.UnsubscribeSession("phone")
+
+	for name, subscription := range map[string]*Subscription{"phone party": phoneParty, "phone chat": phoneChat} {
+		if _, open := <-subscription.Events; open {
+			t.Errorf("%v stream is still open", name)
+		}
+	}
+
+	bus.Publish(PartyTopic("party1"), &models.PresenceEvent{Type: models.PresenceEventPlayerJoinedParty, PartyId: "party1"})
+	select {
+	case _, open := <-laptopParty.Events:
+		if !open {
+			t.Errorf("stream of the other session was closed")
+		}
+	default:
+		t.Errorf("stream of the other session did not receive the event")
+	}
+}
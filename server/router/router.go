@@ -4,7 +4,12 @@ import (
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
 	"lite-social-presence-system/server/apis"
+	"lite-social-presence-system/server/auth"
+	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
+	"lite-social-presence-system/server/scheduler"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -15,46 +20,99 @@ func InitRoutes() *mux.Router {
 
 	// handlers
 
-	// user APIs
+	// APIs that do not need a session
+	r.HandleFunc("/user/register", apis.UserRegisterHandler).Methods(http.MethodPost)
 	r.HandleFunc("/user/login", apis.UserLogInHandler).Methods(http.MethodPatch)
-	r.HandleFunc("/user/logout", apis.UserLogOutHandler).Methods(http.MethodPatch)
+
+	// every other API resolves the caller from the session token
+	authenticated := r.NewRoute().Subrouter()
+	authenticated.Use(auth.HTTPMiddleware)
+
+	// user APIs
+	authenticated.HandleFunc("/user/logout", apis.UserLogOutHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/user/heartbeat", apis.UserHeartbeatHandler).Methods(http.MethodPatch)
 
 	// friends APIs
-	r.HandleFunc("/game/friends", apis.GetFriendsHandler).Methods(http.MethodGet) // /game/friends{id} -> then fetch using mux.Vars to getch path varaibles
-	r.HandleFunc("/game/friends/request", apis.SendFriendRequestHandler).Methods(http.MethodPatch)
-	r.HandleFunc("/game/friends/handle-request", apis.HandleFriendRequest).Methods(http.MethodPatch)
-	r.HandleFunc("/game/friends/remove", apis.RemoveFriends).Methods(http.MethodDelete)
+	authenticated.HandleFunc("/game/friends", apis.GetFriendsHandler).Methods(http.MethodGet)
+	authenticated.HandleFunc("/game/friends/request", apis.SendFriendRequestHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/friends/handle-request", apis.HandleFriendRequest).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/friends/remove", apis.RemoveFriends).Methods(http.MethodDelete)
 
 	// party APIs
-	r.HandleFunc("/game/party/create", apis.CreateGameParty).Methods(http.MethodPost)
-	r.HandleFunc("/game/party/invite", apis.InviteToGamePartyHandler).Methods(http.MethodPatch)
-	r.HandleFunc("/game/party/handle", apis.HandleGamePartyInviteHandler).Methods(http.MethodPatch)
-	r.HandleFunc("/game/party/join", apis.JoinGamePartyHandler).Methods(http.MethodPatch)
-	r.HandleFunc("/game/party/exit", apis.ExitGamePartyHandler).Methods(http.MethodPatch)
-	r.HandleFunc("/game/party/remove", apis.RemoveFromGamePartyHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/party/create", apis.CreateGameParty).Methods(http.MethodPost)
+	authenticated.HandleFunc("/game/party/invite", apis.InviteToGamePartyHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/party/handle", apis.HandleGamePartyInviteHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/party/join", apis.JoinGamePartyHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/party/exit", apis.ExitGamePartyHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/party/remove", apis.RemoveFromGamePartyHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/party/extend", apis.ExtendGamePartyHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/party/end", apis.EndGamePartyHandler).Methods(http.MethodPost)
+	authenticated.HandleFunc("/game/party/transfer-leader", apis.TransferGamePartyLeaderHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/party/cancel-invite", apis.CancelGamePartyInviteHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/party/request-join", apis.RequestJoinGamePartyHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/party/ready-check", apis.StartReadyCheckHandler).Methods(http.MethodPost)
+	authenticated.HandleFunc("/game/party/ready-check/respond", apis.RespondReadyCheckHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/party/finish-match", apis.FinishMatchHandler).Methods(http.MethodPost)
+	authenticated.HandleFunc("/game/party", apis.ListGamePartiesHandler).Methods(http.MethodGet)
+	authenticated.HandleFunc("/game/party/invitations", apis.GetPartyInvitationsHandler).Methods(http.MethodGet)
+	authenticated.HandleFunc("/game/party/{partyId}", apis.GetGamePartyHandler).Methods(http.MethodGet)
+
+	// direct messages APIs
+	authenticated.HandleFunc("/game/messages/send", apis.SendDirectMessageHandler).Methods(http.MethodPost)
+	authenticated.HandleFunc("/game/messages/unread", apis.GetUnreadDirectMessagesHandler).Methods(http.MethodGet)
+	authenticated.HandleFunc("/game/messages/read", apis.MarkDirectMessagesReadHandler).Methods(http.MethodPatch)
+	authenticated.HandleFunc("/game/messages/{friendId}", apis.GetDirectMessagesHandler).Methods(http.MethodGet)
 
 	return r
 }
 
+// settings of the services, resolved from the config with the defaults filled in
+type ServicesConfig struct {
+	PartyDuration        common.PartyDurationConfig
+	MaxPartySize         int
+	AutoPromoteLeader    bool
+	AutoLeaveParty       bool
+	InviteTTL            time.Duration
+	ReadyCheckTimeout    time.Duration
+	ChatHistorySize      int
+	ChatMaxMessageSize   int
+	PersistPartyChat     bool
+	DirectMessageMaxSize int
+}
+
 // init services
-func InitServices(mgDAO mongodao.MongoDAO, userServer *models.UserServer, gamerServer *models.GameServer) {
+func InitServices(mgDAO mongodao.MongoDAO, eventBus eventbus.EventBus, gamerServer *models.GameServer, sessionManager auth.SessionManager, expiryScheduler scheduler.PartyExpiryScheduler, cfg ServicesConfig) {
 
 	// user services
-	apis.InitUserLoginService(mgDAO, userServer)
-	apis.InitUserLogOutService(mgDAO, userServer)
+	apis.InitUserRegisterService(mgDAO)
+	apis.InitUserLoginService(gamerServer, mgDAO, eventBus, sessionManager)
+	apis.InitUserLogOutService(mgDAO, eventBus, sessionManager)
+	apis.InitUserHeartbeatService(gamerServer, mgDAO, eventBus)
 
 	// friends services
 	apis.InitGetUsersService(mgDAO)
-	apis.InitGetFriendsService(mgDAO)
+	apis.InitGetFriendsService(gamerServer, mgDAO, cfg.MaxPartySize)
 	apis.InitSendFriendRequestService(mgDAO)
 	apis.InitHandleFriendRequestService(mgDAO)
 	apis.InitRemoveFriendsService(mgDAO)
 
 	// game party services
-	apis.InitCreateGamePartyService(gamerServer, mgDAO)
-	apis.InitInviteToGamePartyService(gamerServer, mgDAO)
-	apis.InitHandleGamePartyInviteService(gamerServer, mgDAO)
-	apis.InitJoinGamePartyService(gamerServer, mgDAO)
-	apis.InitExitGamePartyService(gamerServer, mgDAO)
-	apis.InitRemoveUsersFromGamePartyService(gamerServer, mgDAO)
+	apis.InitCreateGamePartyService(gamerServer, mgDAO, eventBus, cfg.PartyDuration, expiryScheduler, cfg.MaxPartySize, cfg.AutoLeaveParty)
+	apis.InitInviteToGamePartyService(gamerServer, mgDAO, eventBus, cfg.MaxPartySize)
+	apis.InitHandleGamePartyInviteService(gamerServer, mgDAO, eventBus, cfg.InviteTTL)
+	apis.InitJoinGamePartyService(gamerServer, mgDAO, eventBus, cfg.MaxPartySize, cfg.AutoLeaveParty)
+	apis.InitExitGamePartyService(gamerServer, mgDAO, eventBus)
+	apis.InitRemoveUsersFromGamePartyService(gamerServer, mgDAO, eventBus)
+	apis.InitExtendGamePartyService(gamerServer, mgDAO, cfg.PartyDuration, expiryScheduler)
+	apis.InitEndGamePartyService(gamerServer, mgDAO, eventBus, expiryScheduler)
+	apis.InitGamePartyLeaderService(gamerServer, mgDAO, eventBus, expiryScheduler, cfg.AutoPromoteLeader)
+	apis.InitGetGamePartiesService(gamerServer, mgDAO, cfg.MaxPartySize)
+	apis.InitPartyInvitationsService(gamerServer, cfg.InviteTTL)
+	apis.InitCancelGamePartyInviteService(gamerServer, mgDAO, eventBus)
+	apis.InitRequestJoinGamePartyService(gamerServer, mgDAO, eventBus, cfg.MaxPartySize)
+	apis.InitReadyCheckService(gamerServer, mgDAO, eventBus, cfg.ReadyCheckTimeout)
+	apis.InitPartyChatService(gamerServer, mgDAO, eventBus, cfg.ChatHistorySize, cfg.ChatMaxMessageSize, cfg.PersistPartyChat)
+
+	// direct messages services
+	apis.InitDirectMessagesService(mgDAO, eventBus, cfg.DirectMessageMaxSize)
 }
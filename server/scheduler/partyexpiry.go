@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// PartyExpiryScheduler ends every game party exactly when its duration is over.
+// Parties are kept in a min-heap keyed by end time and a single timer waits for the earliest one
+type PartyExpiryScheduler interface {
+	Schedule(partyId string, endTime time.Time) // adds the party or moves its end time. e.g. duration extended
+	Cancel(partyId string)                      // party ended before its end time
+	Run(ctx context.Context)                    // fires the expiries until ctx is cancelled
+}
+
+type partyExpiry struct {
+	partyId string
+	endTime time.Time
+	index   int // position in the heap, kept up to date by the heap operations
+}
+
+// min-heap of party expiries ordered by end time
+type partyExpiryHeap []*partyExpiry
+
+func (h partyExpiryHeap) Len() int           { return len(h) }
+func (h partyExpiryHeap) Less(i, j int) bool { return h[i].endTime.Before(h[j].endTime) }
+func (h partyExpiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *partyExpiryHeap) Push(x any) {
+	expiry := x.(*partyExpiry)
+	expiry.index = len(*h)
+	*h = append(*h, expiry)
+}
+
+func (h *partyExpiryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	expiry := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return expiry
+}
+
+var partyExpirySchedulerStruct PartyExpiryScheduler
+var partyExpirySchedulerOnce sync.Once
+
+type partyExpiryScheduler struct {
+	expiries partyExpiryHeap
+	byParty  map[string]*partyExpiry
+	onExpire func(partyId string) // called from Run, one party at a time
+	wakeup   chan struct{}        // earliest end time may have changed
+	mutex    sync.Mutex
+}
+
+func InitPartyExpiryScheduler(onExpire func(partyId string)) PartyExpiryScheduler {
+	partyExpirySchedulerOnce.Do(func() {
+		partyExpirySchedulerStruct = &partyExpiryScheduler{
+			byParty:  make(map[string]*partyExpiry),
+			onExpire: onExpire,
+			wakeup:   make(chan struct{}, 1),
+		}
+	})
+	return partyExpirySchedulerStruct
+}
+
+func GetPartyExpiryScheduler() PartyExpiryScheduler {
+	if partyExpirySchedulerStruct == nil {
+		panic("Party Expiry Scheduler not initialized")
+	}
+	return partyExpirySchedulerStruct
+}
+
+func (s *partyExpiryScheduler) Schedule(partyId string, endTime time.Time) {
+	s.mutex.Lock()
+	if expiry, ok := s.byParty[partyId]; ok {
+		expiry.endTime = endTime
+		heap.Fix(&s.expiries, expiry.index)
+	} else {
+		expiry := &partyExpiry{
+			partyId: partyId,
+			endTime: endTime,
+		}
+		heap.Push(&s.expiries, expiry)
+		s.byParty[partyId] = expiry
+	}
+	s.mutex.Unlock()
+
+	s.notify()
+}
+
+func (s *partyExpiryScheduler) Cancel(partyId string) {
+	s.mutex.Lock()
+	if expiry, ok := s.byParty[partyId]; ok {
+		heap.Remove(&s.expiries, expiry.index)
+		delete(s.byParty, partyId)
+	}
+	s.mutex.Unlock()
+
+	s.notify()
+}
+
+func (s *partyExpiryScheduler) Run(ctx context.Context) {
+	for {
+		for _, partyId := range s.popExpired(time.Now()) {
+			s.onExpire(partyId)
+		}
+
+		// sleep until the earliest end time, or until the schedule changes
+		var timer *time.Timer
+		var timerC <-chan time.Time
+		if wait, ok := s.nextWait(time.Now()); ok {
+			timer = time.NewTimer(wait)
+			timerC = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-timerC:
+		case <-s.wakeup:
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+	}
+}
+
+// never blocks. One pending wakeup is enough for Run to look at the heap again
+func (s *partyExpiryScheduler) notify() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// remove and return the parties whose end time is not after now
+func (s *partyExpiryScheduler) popExpired(now time.Time) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var partyIds []string
+	for len(s.expiries) > 0 && !s.expiries[0].endTime.After(now) {
+		expiry := heap.Pop(&s.expiries).(*partyExpiry)
+		delete(s.byParty, expiry.partyId)
+		partyIds = append(partyIds, expiry.partyId)
+	}
+	return partyIds
+}
+
+// time left until the earliest end time. false if no party is scheduled
+func (s *partyExpiryScheduler) nextWait(now time.Time) (time.Duration, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.expiries) == 0 {
+		return 0, false
+	}
+	return s.expiries[0].endTime.Sub(now), true
+}
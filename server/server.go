@@ -2,18 +2,24 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	config "lite-social-presence-system"
 	"lite-social-presence-system/literals"
 	"lite-social-presence-system/models"
 	"lite-social-presence-system/mongodao"
 	"lite-social-presence-system/server/apis"
+	"lite-social-presence-system/server/auth"
 	"lite-social-presence-system/server/common"
+	"lite-social-presence-system/server/eventbus"
 	"lite-social-presence-system/server/router"
+	"lite-social-presence-system/server/scheduler"
 	"log"
 	"net"
 	"net/http"
+	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -27,20 +33,38 @@ func StartServer() {
 		return
 	}
 
-	// Get Client, Context, CalcelFunc and err from connect method.
-	client, ctx, cancel, errConnecting := mongodao.Connect(cfg.MongoURI)
-	if errConnecting != nil {
-		fmt.Println("Error connecting to mongoDB:", errConnecting)
-		return
-	}
+	var mgDAO mongodao.MongoDAO
+	if cfg.UseInMemoryDB {
+		fmt.Println("Using the in-memory database. Data will be lost when the server stops")
+		mgDAO = mongodao.InitInMemoryDao()
+	} else {
+		// Get Client, Context, CalcelFunc and err from connect method.
+		client, ctx, cancel, errConnecting := mongodao.Connect(cfg.MongoURI)
+		if errConnecting != nil {
+			fmt.Println("Error connecting to mongoDB:", errConnecting)
+			return
+		}
 
-	// Release resource when the main function is returned.
-	defer mongodao.Close(client, ctx, cancel)
-	// Ping mongoDB with Ping method
-	mongodao.Ping(client, ctx)
+		// Release resource when the main function is returned.
+		defer mongodao.Close(client, ctx, cancel)
+		// Ping mongoDB with Ping method
+		mongodao.Ping(client, ctx)
 
-	db := client.Database(literals.Database)
-	mgDAO := mongodao.InitMongoDao(client, db)
+		db := client.Database(literals.Database)
+		mgDAO = mongodao.InitMongoDao(client, db)
+	}
+
+	// parties that expired while the server was down are ended before loading the active ones
+	report, err := common.ReconcileExpiredGameParties(context.TODO(), mgDAO)
+	if err != nil {
+		fmt.Println("Error reconciling expired game parties", err)
+		return
+	}
+	logrus.WithFields(logrus.Fields{
+		literals.LLEndedPartyIds:  report.EndedPartyIds,
+		literals.LLResetUserIds:   report.ResetUserIds,
+		literals.LLSkippedUserIds: report.SkippedUserIds,
+	}).Infof("Reconciled game parties expired during downtime. %v parties ended, %v users reset to idle", len(report.EndedPartyIds), len(report.ResetUserIds))
 
 	// initialize the game server
 	gamerServer, err := common.NewGameServer(mgDAO)
@@ -49,94 +73,302 @@ func StartServer() {
 		return
 	}
 
-	// initialize the user server
-	userServer := common.NewUserServer()
+	// real time events are published on the event bus and streamed to the subscribers
+	eventBus := eventbus.InitEventBus()
 
-	// keep checking game party duration in the background
+	// every bound that is not set in the config falls back to its default
+	partyDurationCfg := common.PartyDurationConfig{
+		Default: cfg.DefaultPartyDuration,
+		Min:     cfg.MinPartyDuration,
+		Max:     cfg.MaxPartyDuration,
+	}
+	if partyDurationCfg.Default <= 0 {
+		partyDurationCfg.Default = common.DefaultPartyDuration
+	}
+	if partyDurationCfg.Min <= 0 {
+		partyDurationCfg.Min = common.DefaultMinPartyDuration
+	}
+	if partyDurationCfg.Max <= 0 {
+		partyDurationCfg.Max = common.DefaultMaxPartyDuration
+	}
+	if partyDurationCfg.Min > partyDurationCfg.Default || partyDurationCfg.Default > partyDurationCfg.Max {
+		fmt.Printf("Invalid party duration config. %v should be between %v and %v\n", partyDurationCfg.Default, partyDurationCfg.Min, partyDurationCfg.Max)
+		return
+	}
+
+	maxPartySize := cfg.MaxPartySize
+	if maxPartySize <= 0 {
+		maxPartySize = common.DefaultMaxPartySize
+	}
+	if maxPartySize < 2 {
+		fmt.Printf("Invalid max_party_size %v. A party needs room for the leader and at least one more player\n", maxPartySize)
+		return
+	}
+
+	// SIGINT/SIGTERM cancel ctx and start the graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// background workers stop when ctx is cancelled
+	var workers sync.WaitGroup
+
+	// end every game party exactly when its duration is over
+	expiryScheduler := scheduler.InitPartyExpiryScheduler(func(partyId string) {
+		ExpireGameParty(gamerServer, mgDAO, eventBus, scheduler.GetPartyExpiryScheduler(), partyId)
+	})
+	// parties that were active before the restart. The ones already over end right away
+	gamerServer.Mutex.Lock()
+	for partyId, gameParty := range gamerServer.Parties {
+		expiryScheduler.Schedule(partyId, gameParty.StartTime.Add(gameParty.Duration))
+	}
+	gamerServer.Mutex.Unlock()
+	workers.Add(1)
 	go func() {
-		for {
-			CheckPartyDuration(gamerServer, mgDAO)
-			time.Sleep(1 * time.Minute)
-		}
+		defer workers.Done()
+		expiryScheduler.Run(ctx)
 	}()
 
+	// mark users offline when their client stops sending heartbeats
+	heartbeatTimeout := cfg.HeartbeatTimeout
+	if heartbeatTimeout <= 0 {
+		heartbeatTimeout = common.DefaultHeartbeatTimeout
+	}
+	heartbeatSweepInterval := cfg.HeartbeatSweepInterval
+	if heartbeatSweepInterval <= 0 {
+		heartbeatSweepInterval = common.DefaultHeartbeatSweepInterval
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		runPeriodically(ctx, heartbeatSweepInterval, func() {
+			CheckUserHeartbeats(eventBus, mgDAO, heartbeatTimeout)
+		})
+	}()
+
+	// party invitations that were not answered in time expire
+	inviteTTL := cfg.InviteTTL
+	if inviteTTL <= 0 {
+		inviteTTL = common.DefaultInviteTTL
+	}
+	inviteSweepInterval := cfg.InviteSweepInterval
+	if inviteSweepInterval <= 0 {
+		inviteSweepInterval = common.DefaultInviteSweepInterval
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		runPeriodically(ctx, inviteSweepInterval, func() {
+			err := apis.ExpirePartyInvitations(context.TODO(), gamerServer, mgDAO, eventBus, inviteTTL)
+			if err != nil {
+				fmt.Println("Failed to expire party invitations", err)
+			}
+		})
+	}()
+
+	// ready-checks that are complete or timed out start the match
+	readyCheckTimeout := cfg.ReadyCheckTimeout
+	if readyCheckTimeout <= 0 {
+		readyCheckTimeout = common.DefaultReadyCheckTimeout
+	}
+	readyCheckSweepInterval := cfg.ReadyCheckSweepInterval
+	if readyCheckSweepInterval <= 0 {
+		readyCheckSweepInterval = common.DefaultReadyCheckSweepInterval
+	}
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		runPeriodically(ctx, readyCheckSweepInterval, func() {
+			err := apis.CompleteReadyChecks(context.TODO(), gamerServer, mgDAO, eventBus)
+			if err != nil {
+				fmt.Println("Failed to complete ready-checks", err)
+			}
+		})
+	}()
+
+	// sessions issued on login are required by every other REST and gRPC call
+	sessionTTL := cfg.SessionTTL
+	if sessionTTL <= 0 {
+		sessionTTL = common.DefaultSessionTTL
+	}
+	sessionManager := auth.InitSessionManager(sessionTTL)
+
+	// party chat
+	chatHistorySize := cfg.ChatHistorySize
+	if chatHistorySize <= 0 {
+		chatHistorySize = common.DefaultChatHistorySize
+	}
+	chatMaxMessageSize := cfg.ChatMaxMessageSize
+	if chatMaxMessageSize <= 0 {
+		chatMaxMessageSize = common.DefaultChatMaxMessageSize
+	}
+
+	// direct messages
+	directMessageMaxSize := cfg.DirectMessageMaxSize
+	if directMessageMaxSize <= 0 {
+		directMessageMaxSize = common.DefaultDirectMessageMaxSize
+	}
+
+	shutdownTimeout := cfg.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = common.DefaultShutdownTimeout
+	}
+
 	// init services
-	router.InitServices(mgDAO, userServer, gamerServer)
+	router.InitServices(mgDAO, eventBus, gamerServer, sessionManager, expiryScheduler, router.ServicesConfig{
+		PartyDuration:        partyDurationCfg,
+		MaxPartySize:         maxPartySize,
+		AutoPromoteLeader:    cfg.AutoPromoteLeader,
+		AutoLeaveParty:       cfg.AutoLeaveParty,
+		InviteTTL:            inviteTTL,
+		ReadyCheckTimeout:    readyCheckTimeout,
+		ChatHistorySize:      chatHistorySize,
+		ChatMaxMessageSize:   chatMaxMessageSize,
+		PersistPartyChat:     cfg.PersistPartyChat,
+		DirectMessageMaxSize: directMessageMaxSize,
+	})
 
 	fmt.Println("Starting the server...")
 
 	// concurrently start REST API server and gRPC server
+	r := router.InitRoutes()
+	restServer := &http.Server{
+		Handler: r,
+		Addr:    cfg.RestAPIServerAddress,
+		// WriteTimeout: 200 * time.Second,
+		// ReadTimeout:  200 * time.Second,
+	}
 	go func() {
-		r := router.InitRoutes()
 		fmt.Printf("REST API server started on %v address\n", cfg.RestAPIServerAddress)
-		server := &http.Server{
-			Handler: r,
-			Addr:    cfg.RestAPIServerAddress,
-			// WriteTimeout: 200 * time.Second,
-			// ReadTimeout:  200 * time.Second,
-		}
 		// start REST API server
-		log.Fatal(server.ListenAndServe())
+		if err := restServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("REST API server failed: %v\n", err)
+			stop()
+		}
 	}()
 
-	var wg sync.WaitGroup
-	wg.Add(1)
+	// create a gRPC server
+	grpcServer := apis.InitStreamService(eventBus, gamerServer)
 	go func() {
 		// start gRPC server
 		lis, err := net.Listen(cfg.GRPCNetwork, cfg.GRPCServerAddress)
-
 		if err != nil {
-			log.Fatalf("failed to listen: %v\n", err)
+			log.Printf("failed to listen: %v\n", err)
+			stop()
+			return
 		}
 
-		// create a gRPC server
-		grpcServer := apis.InitStreamService(userServer, gamerServer)
-
 		fmt.Printf("gRPC server started on %v address\n", cfg.GRPCServerAddress)
 		if err := grpcServer.Serve(lis); err != nil {
-			log.Fatalf("failed to serve: %v\n", err)
+			log.Printf("failed to serve: %v\n", err)
+			stop()
 		}
-		wg.Done()
 	}()
 
-	wg.Wait()
-}
+	<-ctx.Done()
+	fmt.Println("Shutting down the server...")
 
-// terminate the game party if the time is over
-func CheckPartyDuration(gameServer *models.GameServer, mgDAO mongodao.MongoDAO) {
+	// stop the background workers
+	workers.Wait()
 
-	var usersStatusToBeUpdated []string
-	var partyIdsToBeTerminated []string
-	gameServer.Mutex.Lock()
-	for partyId, gameParty := range gameServer.Parties {
-		if time.Since(gameParty.StartTime) > gameParty.Duration {
-			logrus.WithFields(logrus.Fields{
-				literals.LLCurrentTimeInUTC:        time.Now().UTC().String(),
-				literals.LLGamePartyStartTimeInUTC: gameParty.StartTime.String(),
-				literals.LLGamePartyDuration:       gameParty.Duration,
-				literals.LLPartyId:                 partyId,
-			}).Info("Terminating party")
-			usersStatusToBeUpdated = append(usersStatusToBeUpdated, string(gameParty.CreatedBy))
-			for userId, status := range gameParty.Players {
-				if status == models.PlayerJoinedStatus {
-					usersStatusToBeUpdated = append(usersStatusToBeUpdated, userId)
-				}
+	// stop accepting REST requests and let the in-flight ones finish
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := restServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("REST API server shutdown: %v\n", err)
+	}
 
-			}
-			partyIdsToBeTerminated = append(partyIdsToBeTerminated, partyId)
-			delete(gameServer.Parties, partyId)
+	// streams only end once their subscription is closed, so close them before stopping gRPC
+	eventBus.CloseAll(&models.PresenceEvent{
+		Type:      models.PresenceEventServerShuttingDown,
+		Timestamp: time.Now(),
+	})
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		log.Println("gRPC server did not stop in time, closing the remaining connections")
+		grpcServer.Stop()
+	}
+
+	// mongoDB is disconnected by the deferred mongodao.Close
+	fmt.Println("Server stopped")
+}
+
+// run fn right away and then every interval until ctx is cancelled
+func runPeriodically(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		fn()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 	}
+}
+
+// terminate the game party once its time is over. Called by the party expiry scheduler
+func ExpireGameParty(gameServer *models.GameServer, mgDAO mongodao.MongoDAO, eventBus eventbus.EventBus, expiryScheduler scheduler.PartyExpiryScheduler, partyId string) {
+
+	gameServer.Mutex.Lock()
+	gameParty, ok := gameServer.Parties[partyId]
+	if ok && time.Since(gameParty.StartTime) < gameParty.Duration {
+		// extended right as it was about to expire
+		expiryScheduler.Schedule(partyId, gameParty.StartTime.Add(gameParty.Duration))
+		gameServer.Mutex.Unlock()
+		return
+	}
+	if ok {
+		logrus.WithFields(logrus.Fields{
+			literals.LLCurrentTimeInUTC:        time.Now().UTC().String(),
+			literals.LLGamePartyStartTimeInUTC: gameParty.StartTime.String(),
+			literals.LLGamePartyDuration:       gameParty.Duration,
+			literals.LLPartyId:                 partyId,
+		}).Info("Terminating party")
+	}
 	gameServer.Mutex.Unlock()
 
-	/*
-		This approach is not efficient for larger dataset.
-		For larger data set, terminate every party separately.
-	*/
-	if len(partyIdsToBeTerminated) > 0 {
-		mgDAO.UpdateGamePartyStatus(context.TODO(), partyIdsToBeTerminated, models.GamePartyStatusOver)
+	if !ok {
+		// party was ended by the leader
+		return
+	}
+
+	err := apis.TerminateGameParties(context.TODO(), gameServer, mgDAO, eventBus, []string{partyId})
+	if err != nil {
+		fmt.Println("Failed to terminate game party", partyId, err)
+	}
+}
+
+// mark users offline if they have not sent a heartbeat within heartbeatTimeout and let their friends know
+func CheckUserHeartbeats(eventBus eventbus.EventBus, mgDAO mongodao.MongoDAO, heartbeatTimeout time.Duration) {
 
-		// update users status to idle
-		mgDAO.UpdateUsersStatus(context.TODO(), usersStatusToBeUpdated, models.UserStatusIdle)
+	lastSeenBefore := time.Now().Add(-heartbeatTimeout)
+	staleUsers, err := mgDAO.FetchStaleOnlineUsers(context.TODO(), lastSeenBefore)
+	if err != nil {
+		fmt.Println("Failed to fetch users with missed heartbeats", err)
+		return
 	}
+	if len(staleUsers) == 0 {
+		return
+	}
+
+	// a heartbeat can still arrive before the update. Those users stay online
+	offlineUserIds, err := apis.MarkStaleUsersOfflineAndNotifyFriends(context.TODO(), mgDAO, eventBus, staleUsers, lastSeenBefore)
+	if err != nil {
+		fmt.Println("Failed to mark users with missed heartbeats offline", err)
+	}
+	if len(offlineUserIds) == 0 {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		literals.LLStaleUserIds:     offlineUserIds,
+		literals.LLHeartbeatTimeout: heartbeatTimeout,
+	}).Info("Marked users offline after missed heartbeats")
 }